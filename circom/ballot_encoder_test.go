@@ -17,25 +17,19 @@ func TestBallotEncoder(t *testing.T) {
 	}
 	// compile and generate proof
 	bInputs, _ := json.Marshal(inputs)
-	proofData, pubSignals, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
+	proof, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
 	if err != nil {
 		t.Errorf("Error compiling and generating proof: %v\n", err)
 		return
 	}
-	t.Log("Proof data:", proofData)
-	t.Log("Public signals:", pubSignals)
-
-	var decPubSignals []string
-	if err := json.Unmarshal([]byte(pubSignals), &decPubSignals); err != nil {
-		t.Errorf("Error unmarshalling public signals: %v\n", err)
-		return
-	}
+	t.Log("Proof data:", proof.A, proof.B, proof.C)
+	t.Log("Public signals:", proof.PublicSignals)
 	expected := EncodeBallot([]int{1, 2, 3, 4, 5}, BallotConfig{
 		MaxCount: 5,
 		Base:     100,
 	})
-	if decPubSignals[0] != expected.String() {
-		t.Errorf("Incorrect public signal: expected %s, got %s\n", expected.String(), decPubSignals[0])
+	if proof.PublicSignals[0].Cmp(expected) != 0 {
+		t.Errorf("Incorrect public signal: expected %s, got %s\n", expected.String(), proof.PublicSignals[0])
 		return
 	}
 }
@@ -0,0 +1,136 @@
+package circom
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+)
+
+// totalCostBits is the number of bits used by the BallotProtocol circuit to
+// compare the total cost of a ballot.
+const totalCostBits = 128
+
+var (
+	// ErrInvalidParams is returned when a ballot protocol parameter can not
+	// be represented in the circuit.
+	ErrInvalidParams = errors.New("invalid ballot protocol params")
+	// ErrMaxCount is returned when max_count is greater than the number of
+	// fields.
+	ErrMaxCount = errors.New("max count exceeds the number of fields")
+	// ErrFieldOutOfBounds is returned when a valid field is out of the
+	// bounds accepted by the circuit.
+	ErrFieldOutOfBounds = errors.New("field out of bounds")
+	// ErrDuplicatedField is returned when uniqueness is forced and a valid
+	// field is repeated.
+	ErrDuplicatedField = errors.New("duplicated field")
+	// ErrTotalCost is returned when the total cost of the ballot is out of
+	// its bounds.
+	ErrTotalCost = errors.New("total cost out of bounds")
+)
+
+// BallotError describes why a ballot is rejected by ValidateBallot. Field is
+// the index of the offending field, or -1 if the error is not related to a
+// single field. Err is one of the ErrXXX errors of this package.
+type BallotError struct {
+	Field  int
+	Err    error
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *BallotError) Error() string {
+	if e.Field < 0 {
+		return fmt.Sprintf("%v: %s", e.Err, e.Reason)
+	}
+	return fmt.Sprintf("field %d: %v: %s", e.Field, e.Err, e.Reason)
+}
+
+// Unwrap returns the sentinel error of the ballot error, to be used with
+// errors.Is.
+func (e *BallotError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateBallot checks the ballot fields provided against the ballot
+// protocol params, enforcing the same constraints that the BallotProtocol
+// circuit does (ballot_protocol.circom), where len(fields) is n_fields:
+//
+//   - max_count must not be greater than n_fields, only the first max_count
+//     fields are valid (MaskGenerator).
+//   - if force_uniqueness is set, the valid fields must be different
+//     (UniqueArray).
+//   - every valid field must be between min_value-1 and max_value+1, both
+//     included (ArrayInBounds). The circuit compares against max_value+1 and
+//     min_value-1, so it accepts one unit more at each side than the params
+//     suggest.
+//   - the total cost, the sum of the valid fields to the power of cost_exp in
+//     the circuit field, must be lower than max_total_cost, or weight if
+//     cost_from_weight is set, and not lower than min_total_cost (SumPow).
+//
+// Negative values can not be represented in the circuit and are rejected.
+// The error returned is a *BallotError.
+func ValidateBallot(fields []int, params BallotProtocolParams) error {
+	for name, value := range map[string]int{
+		"max count":      params.MaxCount,
+		"max value":      params.MaxValue,
+		"min value":      params.MinValue,
+		"max total cost": params.MaxTotalCost,
+		"min total cost": params.MinTotalCost,
+		"cost exponent":  params.CostExp,
+		"weight":         params.Weight,
+	} {
+		if value < 0 {
+			return &BallotError{-1, ErrInvalidParams, fmt.Sprintf("%s must be positive, got %d", name, value)}
+		}
+	}
+	if params.MaxCount > len(fields) {
+		return &BallotError{-1, ErrMaxCount, fmt.Sprintf("max count %d, fields %d", params.MaxCount, len(fields))}
+	}
+	// only the first max_count fields are masked as valid
+	valid := fields[:params.MaxCount]
+	for i, field := range valid {
+		if field < 0 {
+			return &BallotError{i, ErrFieldOutOfBounds, fmt.Sprintf("negative value %d", field)}
+		}
+		if field > params.MaxValue+1 || field < params.MinValue-1 {
+			return &BallotError{i, ErrFieldOutOfBounds, fmt.Sprintf("value %d not in [%d, %d]",
+				field, params.MinValue-1, params.MaxValue+1)}
+		}
+	}
+	if params.ForceUniqueness {
+		seen := make(map[int]int, len(valid))
+		for i, field := range valid {
+			if j, ok := seen[field]; ok {
+				return &BallotError{i, ErrDuplicatedField, fmt.Sprintf("value %d already in field %d", field, j)}
+			}
+			seen[field] = i
+		}
+	}
+	// the powers are computed in the circuit field, so they wrap around its
+	// order like in the circuit
+	totalCost := new(big.Int)
+	exp := big.NewInt(int64(params.CostExp))
+	for _, field := range valid {
+		totalCost.Add(totalCost, new(big.Int).Exp(big.NewInt(int64(field)), exp, constants.Q))
+	}
+	totalCost.Mod(totalCost, constants.Q)
+	if totalCost.BitLen() > totalCostBits {
+		return &BallotError{-1, ErrTotalCost, fmt.Sprintf("total cost %s does not fit in %d bits",
+			totalCost, totalCostBits)}
+	}
+	maxTotalCost := params.MaxTotalCost
+	if params.CostFromWeight {
+		maxTotalCost = params.Weight
+	}
+	if totalCost.Cmp(big.NewInt(int64(maxTotalCost))) >= 0 {
+		return &BallotError{-1, ErrTotalCost, fmt.Sprintf("total cost %s must be lower than %d",
+			totalCost, maxTotalCost)}
+	}
+	if totalCost.Cmp(big.NewInt(int64(params.MinTotalCost))) < 0 {
+		return &BallotError{-1, ErrTotalCost, fmt.Sprintf("total cost %s must not be lower than %d",
+			totalCost, params.MinTotalCost)}
+	}
+	return nil
+}
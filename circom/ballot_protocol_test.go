@@ -2,6 +2,7 @@ package circom
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 )
 
@@ -22,11 +23,64 @@ func TestBallotProtocol(t *testing.T) {
 	}
 	// compile and generate proof
 	bInputs, _ := json.Marshal(inputs)
-	proofData, pubSignals, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
+	proof, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
 	if err != nil {
 		t.Errorf("Error compiling and generating proof: %v\n", err)
 		return
 	}
-	t.Log("Proof data:", proofData)
-	t.Log("Public signals:", pubSignals)
+	t.Log("Proof data:", proof.A, proof.B, proof.C)
+	t.Log("Public signals:", proof.PublicSignals)
+}
+
+func TestValidateBallot(t *testing.T) {
+	// same params than TestBallotProtocol
+	params := BallotProtocolParams{
+		MaxCount:        3,
+		ForceUniqueness: true,
+		MaxValue:        4,
+		MinValue:        0,
+		MaxTotalCost:    15,
+		MinTotalCost:    13,
+		CostExp:         2,
+	}
+	if err := ValidateBallot([]int{1, 2, 3, 0, 0}, params); err != nil {
+		t.Fatalf("Error validating ballot: %v\n", err)
+	}
+	// the circuit accepts max_value+1, total cost 5^2 + 1 + 0 = 26
+	offByOne := params
+	offByOne.MaxTotalCost = 27
+	offByOne.MinTotalCost = 0
+	if err := ValidateBallot([]int{5, 1, 0, 0, 0}, offByOne); err != nil {
+		t.Errorf("Error validating ballot with max_value+1: %v\n", err)
+	}
+	// the fields out of max_count are not checked
+	if err := ValidateBallot([]int{1, 2, 3, 100, 100}, params); err != nil {
+		t.Errorf("Error validating ballot with invalid masked fields: %v\n", err)
+	}
+	// the cost is bounded by the weight if cost_from_weight is set
+	fromWeight := params
+	fromWeight.CostFromWeight = true
+	fromWeight.Weight = 14
+	for _, tc := range []struct {
+		name   string
+		fields []int
+		params BallotProtocolParams
+		field  int
+		err    error
+	}{
+		{"too many valid fields", []int{1, 2}, params, -1, ErrMaxCount},
+		{"over max value", []int{1, 6, 0, 0, 0}, params, 1, ErrFieldOutOfBounds},
+		{"negative field", []int{1, 2, -1, 0, 0}, params, 2, ErrFieldOutOfBounds},
+		{"duplicated field", []int{2, 3, 2, 0, 0}, params, 2, ErrDuplicatedField},
+		{"total cost too low", []int{0, 1, 2, 0, 0}, params, -1, ErrTotalCost},
+		{"total cost too high", []int{1, 2, 4, 0, 0}, params, -1, ErrTotalCost},
+		{"total cost over weight", []int{1, 2, 3, 0, 0}, fromWeight, -1, ErrTotalCost},
+		{"negative param", []int{1, 2, 3, 0, 0}, BallotProtocolParams{MaxCount: 3, MinValue: -1}, -1, ErrInvalidParams},
+	} {
+		err := ValidateBallot(tc.fields, tc.params)
+		ballotErr := &BallotError{}
+		if !errors.As(err, &ballotErr) || !errors.Is(err, tc.err) || ballotErr.Field != tc.field {
+			t.Errorf("Unexpected error with %s: %v\n", tc.name, err)
+		}
+	}
 }
@@ -0,0 +1,60 @@
+package circom
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+// ElectionConfig holds the values that a VocdoniZ proof must be bound to for
+// a ballot to be accepted in an election: the circuit parameters and its
+// verification key, the ballot protocol params and the Paillier public key.
+type ElectionConfig struct {
+	Circuit         VocdoniZCircuit
+	VerificationKey []byte
+	Params          BallotProtocolParams
+	PubKey          *tcpaillier.PubKey
+}
+
+// VerifyBallot checks that the proof provided is a valid VocdoniZ proof for
+// the election configuration provided, and returns the encrypted ballot and
+// the nullifier of the voter, ready to be tallied. It checks that:
+//
+//   - the ballot protocol params of the proof are the configured ones.
+//   - the public key components of the proof are consistent, that is,
+//     (n_plus_one - 1)^(s+1) equals n_to_s_plus_one (see the circom
+//     README), and match the configured public key.
+//   - the ciphertext is in the range of the public key.
+//   - the Groth16 proof is valid for the verification key.
+func VerifyBallot(proof *Proof, config ElectionConfig) (*big.Int, *big.Int, error) {
+	if proof == nil {
+		return nil, nil, fmt.Errorf("nil proof")
+	}
+	if config.PubKey == nil || config.PubKey.N == nil {
+		return nil, nil, fmt.Errorf("public key is required")
+	}
+	signals, err := config.Circuit.DecodePublicSignals(proof.PublicSignals)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid public signals: %w", err)
+	}
+	if signals.Params != config.Params {
+		return nil, nil, fmt.Errorf("ballot protocol params do not match: expected %+v, got %+v",
+			config.Params, signals.Params)
+	}
+	n := new(big.Int).Sub(signals.NPlusOne, big.NewInt(1))
+	nToSPlusOne := new(big.Int).Exp(n, big.NewInt(int64(config.PubKey.S)+1), nil)
+	if nToSPlusOne.Cmp(signals.NToSPlusOne) != 0 {
+		return nil, nil, fmt.Errorf("n_to_s_plus_one is not (n_plus_one - 1)^%d", config.PubKey.S+1)
+	}
+	if n.Cmp(config.PubKey.N) != 0 {
+		return nil, nil, fmt.Errorf("public key does not match")
+	}
+	if signals.Ciphertext.Sign() <= 0 || signals.Ciphertext.Cmp(nToSPlusOne) >= 0 {
+		return nil, nil, fmt.Errorf("ciphertext out of range")
+	}
+	if err := VerifyProof(proof, config.VerificationKey); err != nil {
+		return nil, nil, fmt.Errorf("invalid proof: %w", err)
+	}
+	return signals.Ciphertext, signals.Nullifier, nil
+}
@@ -0,0 +1,75 @@
+package circom
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+func TestVerifyBallot(t *testing.T) {
+	circuit := VocdoniZCircuit{NFields: 5, LSize: 32, NLimbs: 8}
+	params := BallotProtocolParams{
+		MaxCount:     3,
+		MaxValue:     16,
+		MaxTotalCost: 3 * 16 * 16,
+		CostExp:      2,
+		Weight:       1,
+		Base:         16000001,
+	}
+	_, pk, err := tcpaillier.NewKey(128, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	_, otherPK, err := tcpaillier.NewKey(128, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	inputs, err := NewVocdoniZInputs(circuit, []int{3, 5, 2}, params, pk, nil, VoterSecret{Secret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("Error building inputs: %v\n", err)
+	}
+	config := ElectionConfig{
+		Circuit:         circuit,
+		VerificationKey: []byte("{}"),
+		Params:          params,
+		PubKey:          pk,
+	}
+	// the public inputs are checked before the proof, which is not valid
+	proof := testProof()
+	proof.PublicSignals = testPublicSignals(inputs)
+	if _, _, err := VerifyBallot(proof, config); err == nil || !strings.Contains(err.Error(), "invalid proof") {
+		t.Errorf("Expected error verifying the proof, got: %v\n", err)
+	}
+	otherParams := params
+	otherParams.MaxValue++
+	otherKey := config
+	otherKey.PubKey = otherPK
+	for name, tc := range map[string]struct {
+		config  ElectionConfig
+		mutate  func([]*big.Int)
+		message string
+	}{
+		"other params":      {ElectionConfig{circuit, nil, otherParams, pk}, func([]*big.Int) {}, "params do not match"},
+		"other key":         {otherKey, func([]*big.Int) {}, "public key does not match"},
+		"inconsistent key":  {config, func(s []*big.Int) { s[10+circuit.NLimbs].Add(s[10+circuit.NLimbs], big.NewInt(1)) }, "n_to_s_plus_one"},
+		"missing signal":    {config, func(s []*big.Int) { s[len(s)-1] = nil }, "invalid public signals"},
+		"zero ciphertext":   {config, func(s []*big.Int) { zero(s[10+2*circuit.NLimbs : 10+3*circuit.NLimbs]) }, "ciphertext out of range"},
+		"non boolean param": {config, func(s []*big.Int) { s[7] = big.NewInt(3) }, "invalid public signals"},
+	} {
+		proof := testProof()
+		proof.PublicSignals = testPublicSignals(inputs)
+		tc.mutate(proof.PublicSignals)
+		if _, _, err := VerifyBallot(proof, tc.config); err == nil || !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("Expected error with %s, got: %v\n", name, err)
+		}
+	}
+}
+
+// zero sets every element of the array provided to zero.
+func zero(arr []*big.Int) {
+	for _, x := range arr {
+		x.SetInt64(0)
+	}
+}
@@ -5,10 +5,17 @@ import (
 	"log"
 	"math/big"
 	"testing"
+
+	"github.com/niclabs/tcpaillier"
 )
 
 func TestPaillierCipher(t *testing.T) {
 	var (
+		// paillier parameters
+		bitSize = 64
+		s       = uint8(1)
+		l       = uint8(5) // number of shares
+		k       = uint8(3) // threshold
 		// circuit parameters
 		lSize  = 32
 		nLimbs = 16
@@ -16,31 +23,33 @@ func TestPaillierCipher(t *testing.T) {
 		wasmFile = "./artifacts/paillier_cipher_test.wasm"
 		zkeyFile = "./artifacts/paillier_cipher_test_pkey.zkey"
 	)
+	// generate the public key
+	_, pk, err := tcpaillier.NewKey(bitSize, s, l, k)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
 	// encrypt
 	raw, _ := new(big.Int).SetString("102030405", 10)
-	pk, rnd, c, err := EncryptWithPaillier(raw)
+	enc, err := EncryptWithPaillier(pk, raw, nil, lSize, nLimbs)
 	if err != nil {
 		log.Fatalf("Error encrypting: %v\n", err)
 		return
 	}
-	// get the cached constant values
-	cv := pk.Cache()
-	rToNToS := new(big.Int).Exp(rnd, cv.NToS, cv.NToSPlusOne)
 	// init inputs
 	inputs := map[string]any{
 		"m":               raw.String(),
-		"n_plus_one":      BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, cv.NPlusOne)),
-		"r_to_n_to_s":     BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, rToNToS)),
-		"n_to_s_plus_one": BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, cv.NToSPlusOne)),
-		"ciphertext":      BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, c)),
+		"n_plus_one":      enc.Inputs.NPlusOne,
+		"r_to_n_to_s":     enc.Inputs.RToNToS,
+		"n_to_s_plus_one": enc.Inputs.NToSPlusOne,
+		"ciphertext":      enc.Inputs.Ciphertext,
 	}
 	bInputs, _ := json.Marshal(inputs)
 	log.Println("Inputs:", string(bInputs))
-	proofData, pubSignals, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
+	proof, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
 	if err != nil {
 		t.Errorf("Error compiling and generating proof: %v\n", err)
 		return
 	}
-	log.Println("Proof data:", proofData)
-	log.Println("Public signals:", pubSignals)
+	log.Println("Proof data:", proof.A, proof.B, proof.C)
+	log.Println("Public signals:", proof.PublicSignals)
 }
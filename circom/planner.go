@@ -0,0 +1,194 @@
+package circom
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+const (
+	// CircuitPlaintextBits is the number of bits of the exponent supported by
+	// the BigModExp template used in EncryptWithPaillier, so every encoded
+	// ballot must fit in it.
+	CircuitPlaintextBits = 100
+	// MinKeyBitSize is the minimum Paillier key size supported by tcpaillier.
+	MinKeyBitSize = 64
+	// DefaultLimbSize is the default size of each limb (l_size) used to split
+	// big integers into circuit inputs.
+	DefaultLimbSize = 32
+)
+
+// ElectionParams holds the parameters of an election required to plan the
+// ballot encoding and the Paillier key that will be used to encrypt ballots.
+type ElectionParams struct {
+	// MaxVoters is the maximum number of voters, ignored if Weights is set.
+	MaxVoters int
+	// MaxValue is the maximum value of a single field (max_value). The
+	// circuit accepts up to max_value+1, see ValidateBallot.
+	MaxValue int
+	// MaxCount is the number of fields of every ballot (max_count).
+	MaxCount int
+	// CostExp is the exponent used to compute the cost of the fields
+	// (cost_exp).
+	CostExp int
+	// MaxTotalCost is the upper bound of the total cost of a ballot
+	// (max_total_cost), optional. If it is set, it also limits the maximum
+	// value of a single field.
+	MaxTotalCost int
+	// Weights contains the weight of every voter when the total cost is
+	// bounded by the voter weight (cost_from_weight), optional. If it is set,
+	// the number of voters is the number of weights.
+	Weights []int
+	// BitSize is the size of the Paillier key, optional. If it is set, the
+	// plan contains the minimum s for it, otherwise the plan contains the
+	// minimum key size with s = 1.
+	BitSize int
+	// LimbSize is the size of each limb of the circuit (l_size), optional. By
+	// default DefaultLimbSize.
+	LimbSize int
+}
+
+// BallotPlan contains the ballot configuration, the Paillier key parameters
+// and the circuit parameters derived from some ElectionParams.
+type BallotPlan struct {
+	// Config is the ballot configuration with the minimum safe base.
+	Config BallotConfig
+	// BallotBits is the number of bits required by a single encoded ballot.
+	BallotBits int
+	// PlaintextBits is the number of bits required by the encoded tally.
+	PlaintextBits int
+	// BitSize and S are the Paillier key parameters, n^S can hold the
+	// encoded tally.
+	BitSize int
+	S       uint8
+	// LSize and NLimbs are the circuit parameters of the EncryptWithPaillier
+	// template, required to hold n^(S+1).
+	LSize  int
+	NLimbs int
+}
+
+// PlanBallot calculates the minimum safe base to encode the ballots of an
+// election with the parameters provided, so that the total of every field
+// never carries into the next position, and the Paillier key and circuit
+// parameters required to encrypt the encoded tally. The maximum value of a
+// field is the minimum between MaxValue+1, which the circuit accepts, and the
+// greatest value whose cost is lower than the cost bound (MaxTotalCost or the
+// greatest weight). It returns
+// an error if the parameters are not valid or if the encoded ballot does not
+// fit in the circuit.
+func PlanBallot(params ElectionParams) (*BallotPlan, error) {
+	if params.MaxCount <= 0 {
+		return nil, fmt.Errorf("max count must be greater than 0, got %d", params.MaxCount)
+	}
+	if params.MaxValue <= 0 {
+		return nil, fmt.Errorf("max value must be greater than 0, got %d", params.MaxValue)
+	}
+	if params.CostExp < 0 {
+		return nil, fmt.Errorf("cost exponent must be positive, got %d", params.CostExp)
+	}
+	if params.MaxTotalCost < 0 {
+		return nil, fmt.Errorf("max total cost must be positive, got %d", params.MaxTotalCost)
+	}
+	voters := params.MaxVoters
+	// get the greatest cost bound, the max total cost or the greatest weight
+	costBound := params.MaxTotalCost
+	if len(params.Weights) > 0 {
+		voters = len(params.Weights)
+		costBound = 0
+		for i, weight := range params.Weights {
+			if weight <= 0 {
+				return nil, fmt.Errorf("weight of voter %d must be greater than 0, got %d", i, weight)
+			}
+			costBound = max(costBound, weight)
+		}
+	}
+	if voters <= 0 {
+		return nil, fmt.Errorf("max voters must be greater than 0, got %d", voters)
+	}
+	// the circuit accepts fields up to max_value+1 (ArrayInBounds), and
+	// requires total_cost < bound, so the cost of a single field is at most
+	// bound - 1
+	maxValue := params.MaxValue + 1
+	if costBound > 0 && params.CostExp > 0 {
+		maxCostValue := intRoot(big.NewInt(int64(costBound-1)), params.CostExp)
+		if maxCostValue.Cmp(big.NewInt(int64(maxValue))) < 0 {
+			maxValue = int(maxCostValue.Int64())
+		}
+		if maxValue == 0 {
+			return nil, fmt.Errorf("cost bound %d does not allow any field greater than 0", costBound)
+		}
+	}
+	// base = voters * maxValue + 1
+	bigBase := new(big.Int).Mul(big.NewInt(int64(voters)), big.NewInt(int64(maxValue)))
+	bigBase.Add(bigBase, big.NewInt(1))
+	if !bigBase.IsInt64() {
+		return nil, fmt.Errorf("base %s overflows", bigBase)
+	}
+	config := BallotConfig{
+		MaxCount: params.MaxCount,
+		MaxValue: maxValue,
+		Base:     int(bigBase.Int64()),
+	}
+	// the greatest ballot has every field set to maxValue
+	maxBallot := make([]int, params.MaxCount)
+	for i := range maxBallot {
+		maxBallot[i] = maxValue
+	}
+	ballotBits := EncodeBallot(maxBallot, config).BitLen()
+	if ballotBits > CircuitPlaintextBits {
+		return nil, fmt.Errorf("encoded ballot requires %d bits but the circuit supports up to %d",
+			ballotBits, CircuitPlaintextBits)
+	}
+	// the greatest tally is base^maxCount - 1
+	maxTally := new(big.Int).Sub(powBigInt(config.Base, config.MaxCount), big.NewInt(1))
+	plaintextBits := maxTally.BitLen()
+	// n has bitSize bits, so n^s >= 2^(s*(bitSize-1)) must hold the tally
+	bitSize, s := params.BitSize, 1
+	if bitSize == 0 {
+		bitSize = max(plaintextBits+1, MinKeyBitSize)
+	} else {
+		if bitSize < MinKeyBitSize {
+			return nil, fmt.Errorf("key size must be at least %d bits, got %d", MinKeyBitSize, bitSize)
+		}
+		s = max((plaintextBits+bitSize-2)/(bitSize-1), 1)
+		if s > math.MaxUint8 {
+			return nil, fmt.Errorf("key size %d requires s = %d, but the maximum is %d", bitSize, s, math.MaxUint8)
+		}
+	}
+	lSize := params.LimbSize
+	if lSize == 0 {
+		lSize = DefaultLimbSize
+	}
+	if lSize < 0 {
+		return nil, fmt.Errorf("limb size must be greater than 0, got %d", lSize)
+	}
+	// n^(s+1) has at most (s+1)*bitSize bits
+	modBits := (s + 1) * bitSize
+	return &BallotPlan{
+		Config:        config,
+		BallotBits:    ballotBits,
+		PlaintextBits: plaintextBits,
+		BitSize:       bitSize,
+		S:             uint8(s),
+		LSize:         lSize,
+		NLimbs:        (modBits + lSize - 1) / lSize,
+	}, nil
+}
+
+// intRoot returns the greatest integer r such that r^e <= x.
+func intRoot(x *big.Int, e int) *big.Int {
+	// binary search in [0, 2^(bitlen(x)/e + 1))
+	lo := big.NewInt(0)
+	hi := new(big.Int).Lsh(big.NewInt(1), uint(x.BitLen()/e+1))
+	bigE := big.NewInt(int64(e))
+	for new(big.Int).Sub(hi, lo).Cmp(big.NewInt(1)) > 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if new(big.Int).Exp(mid, bigE, nil).Cmp(x) <= 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
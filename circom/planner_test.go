@@ -0,0 +1,113 @@
+package circom
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPlanBallot(t *testing.T) {
+	plan, err := PlanBallot(ElectionParams{
+		MaxVoters: 1000,
+		MaxValue:  16,
+		MaxCount:  5,
+		CostExp:   2,
+	})
+	if err != nil {
+		t.Fatalf("Error planning ballot: %v\n", err)
+	}
+	// the circuit accepts fields up to max_value+1
+	if plan.Config.MaxValue != 17 || plan.Config.Base != 17001 {
+		t.Errorf("Unexpected config: %+v\n", plan.Config)
+	}
+	// the greatest tally must fit in n^s with n >= 2^(bitSize-1)
+	maxTally := new(big.Int).Sub(powBigInt(plan.Config.Base, plan.Config.MaxCount), big.NewInt(1))
+	if maxTally.BitLen() > int(plan.S)*(plan.BitSize-1) {
+		t.Errorf("Tally of %d bits does not fit in a key of %d bits with s = %d\n",
+			maxTally.BitLen(), plan.BitSize, plan.S)
+	}
+	if plan.NLimbs*plan.LSize < int(plan.S+1)*plan.BitSize {
+		t.Errorf("Circuit limbs (%d x %d) can not hold n^(s+1)\n", plan.NLimbs, plan.LSize)
+	}
+	// the greatest tally can be decoded without overflow
+	allMax := make([]int, plan.Config.MaxCount)
+	for i := range allMax {
+		allMax[i] = plan.Config.MaxValue
+	}
+	tally := new(big.Int).Mul(EncodeBallot(allMax, plan.Config), big.NewInt(1000))
+	results, err := DecodeTally(tally, plan.Config, 1000)
+	if err != nil {
+		t.Fatalf("Error decoding the greatest tally: %v\n", err)
+	}
+	for i, result := range results {
+		if result.Int64() != 17000 {
+			t.Errorf("Unexpected result for field %d: expected 17000, got %s\n", i, result)
+		}
+	}
+}
+
+func TestPlanBallotCircuitMaxValue(t *testing.T) {
+	params := ElectionParams{MaxVoters: 3, MaxValue: 4, MaxCount: 2}
+	plan, err := PlanBallot(params)
+	if err != nil {
+		t.Fatalf("Error planning ballot: %v\n", err)
+	}
+	// every voter sends max_value+1 in every field, which the circuit
+	// accepts, and the totals must not carry into the next field
+	ballot := []int{params.MaxValue + 1, params.MaxValue + 1}
+	if err := ValidateBallot(ballot, BallotProtocolParams{
+		MaxCount:     params.MaxCount,
+		MaxValue:     params.MaxValue,
+		MaxTotalCost: 100,
+		CostExp:      1,
+	}); err != nil {
+		t.Fatalf("Error validating ballot: %v\n", err)
+	}
+	tally := big.NewInt(0)
+	for i := 0; i < params.MaxVoters; i++ {
+		tally.Add(tally, EncodeBallot(ballot, plan.Config))
+	}
+	results, err := DecodeTally(tally, plan.Config, params.MaxVoters)
+	if err != nil {
+		t.Fatalf("Error decoding tally: %v\n", err)
+	}
+	for i, result := range results {
+		if result.Int64() != int64(params.MaxVoters*(params.MaxValue+1)) {
+			t.Errorf("Unexpected result for field %d: expected %d, got %s\n",
+				i, params.MaxVoters*(params.MaxValue+1), result)
+		}
+	}
+}
+
+func TestPlanBallotCostBound(t *testing.T) {
+	// with cost_exp = 2 and weights up to 10, a field can not exceed 3
+	plan, err := PlanBallot(ElectionParams{
+		MaxValue: 16,
+		MaxCount: 3,
+		CostExp:  2,
+		Weights:  []int{1, 5, 10},
+		BitSize:  64,
+	})
+	if err != nil {
+		t.Fatalf("Error planning ballot: %v\n", err)
+	}
+	if plan.Config.MaxValue != 3 || plan.Config.Base != 10 {
+		t.Errorf("Unexpected config: %+v\n", plan.Config)
+	}
+	if plan.S != 1 {
+		t.Errorf("Unexpected s: expected 1, got %d\n", plan.S)
+	}
+}
+
+func TestPlanBallotInfeasible(t *testing.T) {
+	for name, params := range map[string]ElectionParams{
+		"no fields":       {MaxVoters: 10, MaxValue: 5},
+		"no voters":       {MaxValue: 5, MaxCount: 5},
+		"ballot too big":  {MaxVoters: 1000000, MaxValue: 1000, MaxCount: 8},
+		"key too small":   {MaxVoters: 10, MaxValue: 5, MaxCount: 5, BitSize: 32},
+		"zero cost bound": {MaxVoters: 10, MaxValue: 5, MaxCount: 5, CostExp: 2, MaxTotalCost: 1},
+	} {
+		if _, err := PlanBallot(params); err == nil {
+			t.Errorf("Expected error planning ballot with %s\n", name)
+		}
+	}
+}
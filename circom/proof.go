@@ -0,0 +1,287 @@
+package circom
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-rapidsnark/types"
+)
+
+const (
+	// proofProtocol and proofCurve are the values of the protocol and curve
+	// fields of the snarkjs proofs generated by this package.
+	proofProtocol = "groth16"
+	proofCurve    = "bn128"
+	// proofVersion is the first byte of the binary encoding of a proof.
+	proofVersion = 1
+	// coordSize is the number of bytes of every coordinate of a point in the
+	// binary encoding of a proof, and of every public signal.
+	coordSize = 32
+	// proofPointsSize is the number of bytes of the points of a proof in its
+	// binary encoding: A and C have two coordinates and B has four.
+	proofPointsSize = 8 * coordSize
+)
+
+// Proof is a Groth16 proof over the BN254 curve and its public signals. The
+// points are stored in affine coordinates, A and C in G1 as (x, y), and B in
+// G2 as ((x0, x1), (y0, y1)).
+type Proof struct {
+	A             [2]*big.Int
+	B             [2][2]*big.Int
+	C             [2]*big.Int
+	PublicSignals []*big.Int
+}
+
+// snarkjsProof is the JSON representation of a proof used by snarkjs, where
+// the points have a third z coordinate.
+type snarkjsProof struct {
+	A        []string   `json:"pi_a"`
+	B        [][]string `json:"pi_b"`
+	C        []string   `json:"pi_c"`
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve,omitempty"`
+}
+
+// proofJSON is the JSON representation of a Proof, with the same layout as
+// types.ZKProof.
+type proofJSON struct {
+	Proof         *snarkjsProof `json:"proof"`
+	PublicSignals []string      `json:"pub_signals"`
+}
+
+// NewProofFromZKProof returns the Proof of the go-rapidsnark proof provided,
+// validating every value.
+func NewProofFromZKProof(zkProof *types.ZKProof) (*Proof, error) {
+	if zkProof == nil || zkProof.Proof == nil {
+		return nil, fmt.Errorf("nil proof")
+	}
+	return newProof(&snarkjsProof{
+		A:        zkProof.Proof.A,
+		B:        zkProof.Proof.B,
+		C:        zkProof.Proof.C,
+		Protocol: zkProof.Proof.Protocol,
+	}, zkProof.PubSignals)
+}
+
+// ParseSnarkJSProof decodes a proof from the snarkjs JSON files of the proof
+// and the public signals.
+func ParseSnarkJSProof(proofData, pubSignals []byte) (*Proof, error) {
+	proof := &snarkjsProof{}
+	if err := json.Unmarshal(proofData, proof); err != nil {
+		return nil, fmt.Errorf("error decoding proof: %w", err)
+	}
+	signals := []string{}
+	if err := json.Unmarshal(pubSignals, &signals); err != nil {
+		return nil, fmt.Errorf("error decoding public signals: %w", err)
+	}
+	return newProof(proof, signals)
+}
+
+// ZKProof returns the proof as a go-rapidsnark proof, to be used with its
+// verifier.
+func (p *Proof) ZKProof() *types.ZKProof {
+	proof := p.snarkjs()
+	return &types.ZKProof{
+		Proof: &types.ProofData{
+			A:        proof.A,
+			B:        proof.B,
+			C:        proof.C,
+			Protocol: proof.Protocol,
+		},
+		PubSignals: BigIntArrayToStringArray(p.PublicSignals),
+	}
+}
+
+// SnarkJS returns the proof and its public signals encoded as the snarkjs
+// JSON files, to be used with snarkjs tools.
+func (p *Proof) SnarkJS() ([]byte, []byte, error) {
+	proofData, err := json.Marshal(p.snarkjs())
+	if err != nil {
+		return nil, nil, err
+	}
+	pubSignals, err := json.Marshal(BigIntArrayToStringArray(p.PublicSignals))
+	if err != nil {
+		return nil, nil, err
+	}
+	return proofData, pubSignals, nil
+}
+
+// MarshalJSON encodes the proof and its public signals with the layout of
+// types.ZKProof, where the proof is compatible with snarkjs.
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&proofJSON{
+		Proof:         p.snarkjs(),
+		PublicSignals: BigIntArrayToStringArray(p.PublicSignals),
+	})
+}
+
+// UnmarshalJSON decodes and validates a proof encoded with MarshalJSON.
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	decoded := &proofJSON{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+	if decoded.Proof == nil {
+		return fmt.Errorf("missing proof")
+	}
+	proof, err := newProof(decoded.Proof, decoded.PublicSignals)
+	if err != nil {
+		return err
+	}
+	*p = *proof
+	return nil
+}
+
+// MarshalBinary encodes the proof in a compact form: the version byte, the
+// affine coordinates of A, B and C as 32 bytes big-endian numbers, the number
+// of public signals as an uvarint and every public signal as a 32 bytes
+// big-endian number.
+func (p *Proof) MarshalBinary() ([]byte, error) {
+	coords := []*big.Int{p.A[0], p.A[1], p.B[0][0], p.B[0][1], p.B[1][0], p.B[1][1], p.C[0], p.C[1]}
+	buf := make([]byte, 0, 1+proofPointsSize+binary.MaxVarintLen64+len(p.PublicSignals)*coordSize)
+	buf = append(buf, proofVersion)
+	appendValue := func(x *big.Int) error {
+		if x == nil || x.Sign() < 0 || x.BitLen() > coordSize*8 {
+			return fmt.Errorf("invalid proof value %v", x)
+		}
+		buf = append(buf, x.FillBytes(make([]byte, coordSize))...)
+		return nil
+	}
+	for _, x := range coords {
+		if err := appendValue(x); err != nil {
+			return nil, err
+		}
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(p.PublicSignals)))
+	for _, x := range p.PublicSignals {
+		if err := appendValue(x); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes and validates a proof encoded with MarshalBinary.
+func (p *Proof) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+proofPointsSize+1 {
+		return fmt.Errorf("data too short")
+	}
+	if data[0] != proofVersion {
+		return fmt.Errorf("unsupported proof version %d", data[0])
+	}
+	coords := make([]*big.Int, 8)
+	for i := range coords {
+		offset := 1 + i*coordSize
+		coords[i] = new(big.Int).SetBytes(data[offset : offset+coordSize])
+	}
+	rest := data[1+proofPointsSize:]
+	count, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return fmt.Errorf("invalid number of public signals")
+	}
+	rest = rest[n:]
+	if count != uint64(len(rest)/coordSize) || len(rest)%coordSize != 0 {
+		return fmt.Errorf("expected %d public signals, got %d bytes", count, len(rest))
+	}
+	signals := make([]*big.Int, count)
+	for i := range signals {
+		signals[i] = new(big.Int).SetBytes(rest[i*coordSize : (i+1)*coordSize])
+		if signals[i].Cmp(constants.Q) >= 0 {
+			return fmt.Errorf("public signal %d out of the field", i)
+		}
+	}
+	*p = Proof{
+		A:             [2]*big.Int{coords[0], coords[1]},
+		B:             [2][2]*big.Int{{coords[2], coords[3]}, {coords[4], coords[5]}},
+		C:             [2]*big.Int{coords[6], coords[7]},
+		PublicSignals: signals,
+	}
+	return nil
+}
+
+// snarkjs returns the snarkjs representation of the proof.
+func (p *Proof) snarkjs() *snarkjsProof {
+	return &snarkjsProof{
+		A: []string{p.A[0].String(), p.A[1].String(), "1"},
+		B: [][]string{
+			{p.B[0][0].String(), p.B[0][1].String()},
+			{p.B[1][0].String(), p.B[1][1].String()},
+			{"1", "0"},
+		},
+		C:        []string{p.C[0].String(), p.C[1].String(), "1"},
+		Protocol: proofProtocol,
+		Curve:    proofCurve,
+	}
+}
+
+// newProof decodes and validates the snarkjs proof and the public signals
+// provided. The points must be in affine coordinates (z = 1), as generated by
+// snarkjs and rapidsnark.
+func newProof(proof *snarkjsProof, pubSignals []string) (*Proof, error) {
+	if proof.Protocol != "" && proof.Protocol != proofProtocol {
+		return nil, fmt.Errorf("unsupported protocol %q", proof.Protocol)
+	}
+	if proof.Curve != "" && proof.Curve != proofCurve {
+		return nil, fmt.Errorf("unsupported curve %q", proof.Curve)
+	}
+	a, err := parseG1(proof.A)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pi_a: %w", err)
+	}
+	c, err := parseG1(proof.C)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pi_c: %w", err)
+	}
+	if len(proof.B) != 3 || len(proof.B[2]) != 2 || proof.B[2][0] != "1" || proof.B[2][1] != "0" {
+		return nil, fmt.Errorf("invalid pi_b: expected 3 affine coordinates")
+	}
+	var b [2][2]*big.Int
+	for i := range b {
+		if len(proof.B[i]) != 2 {
+			return nil, fmt.Errorf("invalid pi_b: expected 2 elements in coordinate %d", i)
+		}
+		for j := range b[i] {
+			if b[i][j], err = parseCoord(proof.B[i][j]); err != nil {
+				return nil, fmt.Errorf("invalid pi_b: %w", err)
+			}
+		}
+	}
+	signals := make([]*big.Int, len(pubSignals))
+	for i, signal := range pubSignals {
+		var ok bool
+		signals[i], ok = new(big.Int).SetString(signal, 10)
+		if !ok || signals[i].Sign() < 0 || signals[i].Cmp(constants.Q) >= 0 {
+			return nil, fmt.Errorf("invalid public signal %d: %q", i, signal)
+		}
+	}
+	return &Proof{A: a, B: b, C: c, PublicSignals: signals}, nil
+}
+
+// parseG1 decodes the affine coordinates of a G1 point in the snarkjs
+// format.
+func parseG1(point []string) ([2]*big.Int, error) {
+	if len(point) != 3 || point[2] != "1" {
+		return [2]*big.Int{}, fmt.Errorf("expected 3 affine coordinates")
+	}
+	x, err := parseCoord(point[0])
+	if err != nil {
+		return [2]*big.Int{}, err
+	}
+	y, err := parseCoord(point[1])
+	if err != nil {
+		return [2]*big.Int{}, err
+	}
+	return [2]*big.Int{x, y}, nil
+}
+
+// parseCoord decodes a decimal coordinate that fits in coordSize bytes.
+func parseCoord(s string) (*big.Int, error) {
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok || x.Sign() < 0 || x.BitLen() > coordSize*8 {
+		return nil, fmt.Errorf("invalid coordinate %q", s)
+	}
+	return x, nil
+}
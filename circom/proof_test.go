@@ -0,0 +1,114 @@
+package circom
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+)
+
+// testProof returns a proof with arbitrary values, it is not valid for any
+// circuit but it is enough to test the encodings.
+func testProof() *Proof {
+	coord := func(i int64) *big.Int {
+		x := new(big.Int).Lsh(big.NewInt(i), 240)
+		return x.Add(x, big.NewInt(i))
+	}
+	return &Proof{
+		A:             [2]*big.Int{coord(1), coord(2)},
+		B:             [2][2]*big.Int{{coord(3), coord(4)}, {coord(5), coord(6)}},
+		C:             [2]*big.Int{coord(7), big.NewInt(0)},
+		PublicSignals: []*big.Int{big.NewInt(1), big.NewInt(0), new(big.Int).Sub(constants.Q, big.NewInt(1))},
+	}
+}
+
+func TestProofEncoding(t *testing.T) {
+	proof := testProof()
+	// json
+	bProof, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatalf("Error encoding proof: %v\n", err)
+	}
+	decoded := &Proof{}
+	if err := json.Unmarshal(bProof, decoded); err != nil {
+		t.Fatalf("Error decoding proof: %v\n", err)
+	}
+	if bDecoded, _ := json.Marshal(decoded); !bytes.Equal(bProof, bDecoded) {
+		t.Errorf("Unexpected JSON round trip:\n%s\n%s\n", bProof, bDecoded)
+	}
+	// snarkjs
+	proofData, pubSignals, err := proof.SnarkJS()
+	if err != nil {
+		t.Fatalf("Error encoding snarkjs proof: %v\n", err)
+	}
+	snarkjs := map[string]any{}
+	if err := json.Unmarshal(proofData, &snarkjs); err != nil {
+		t.Fatalf("Error decoding snarkjs proof: %v\n", err)
+	}
+	if snarkjs["protocol"] != "groth16" || snarkjs["curve"] != "bn128" || len(snarkjs["pi_b"].([]any)) != 3 {
+		t.Errorf("Unexpected snarkjs proof: %s\n", proofData)
+	}
+	if decoded, err = ParseSnarkJSProof(proofData, pubSignals); err != nil {
+		t.Fatalf("Error decoding snarkjs proof: %v\n", err)
+	}
+	if bDecoded, _ := json.Marshal(decoded); !bytes.Equal(bProof, bDecoded) {
+		t.Error("Unexpected snarkjs round trip")
+	}
+	// go-rapidsnark
+	if decoded, err = NewProofFromZKProof(proof.ZKProof()); err != nil {
+		t.Fatalf("Error converting from ZKProof: %v\n", err)
+	}
+	if bDecoded, _ := json.Marshal(decoded); !bytes.Equal(bProof, bDecoded) {
+		t.Error("Unexpected ZKProof round trip")
+	}
+	// binary
+	bin, err := proof.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error encoding binary proof: %v\n", err)
+	}
+	if expected := 1 + proofPointsSize + 1 + 3*coordSize; len(bin) != expected {
+		t.Errorf("Unexpected binary size: expected %d, got %d\n", expected, len(bin))
+	}
+	decoded = &Proof{}
+	if err := decoded.UnmarshalBinary(bin); err != nil {
+		t.Fatalf("Error decoding binary proof: %v\n", err)
+	}
+	if bDecoded, _ := json.Marshal(decoded); !bytes.Equal(bProof, bDecoded) {
+		t.Error("Unexpected binary round trip")
+	}
+}
+
+func TestInvalidProof(t *testing.T) {
+	proofData, pubSignals, err := testProof().SnarkJS()
+	if err != nil {
+		t.Fatalf("Error encoding snarkjs proof: %v\n", err)
+	}
+	for name, data := range map[string][]byte{
+		"non affine point":   bytes.Replace(proofData, []byte(`"1"],"pi_b"`), []byte(`"2"],"pi_b"`), 1),
+		"bad protocol":       bytes.Replace(proofData, []byte(`groth16`), []byte(`plonk`), 1),
+		"missing coordinate": bytes.Replace(proofData, []byte(`["1","0"]`), []byte(`["1"]`), 1),
+	} {
+		if _, err := ParseSnarkJSProof(data, pubSignals); err == nil {
+			t.Errorf("Expected error decoding proof with %s\n", name)
+		}
+	}
+	if _, err := ParseSnarkJSProof(proofData, []byte(`["`+constants.Q.String()+`"]`)); err == nil {
+		t.Error("Expected error decoding a public signal out of the field")
+	}
+	bin, err := testProof().MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error encoding binary proof: %v\n", err)
+	}
+	for name, data := range map[string][]byte{
+		"bad version":   append([]byte{proofVersion + 1}, bin[1:]...),
+		"truncated":     bin[:len(bin)-1],
+		"trailing data": append(append([]byte{}, bin...), 0),
+		"too short":     bin[:proofPointsSize],
+	} {
+		if err := new(Proof).UnmarshalBinary(data); err == nil {
+			t.Errorf("Expected error decoding binary proof with %s\n", name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package circom
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/iden3/go-rapidsnark/prover"
+	"github.com/iden3/go-rapidsnark/witness"
+)
+
+// Prover generates and verifies Groth16 proofs of a circuit, loading its
+// artifacts (wasm, proving key and verification key) only once. It is safe
+// for concurrent use: the witness calculator, which is not, is protected by
+// a mutex, while the proof generation and verification run in parallel.
+type Prover struct {
+	calcMtx sync.Mutex
+	calc    *witness.Circom2WitnessCalculator
+	zkey    []byte
+	vkey    []byte
+}
+
+// NewProver reads the circuit artifacts from the files provided and returns
+// a Prover for them. The verification key file is optional, if it is empty
+// the Prover can not verify proofs.
+func NewProver(wasmFile, zkeyFile, vkeyFile string) (*Prover, error) {
+	bWasm, err := os.ReadFile(wasmFile)
+	if err != nil {
+		return nil, err
+	}
+	bZkey, err := os.ReadFile(zkeyFile)
+	if err != nil {
+		return nil, err
+	}
+	var bVkey []byte
+	if vkeyFile != "" {
+		if bVkey, err = os.ReadFile(vkeyFile); err != nil {
+			return nil, err
+		}
+	}
+	return NewProverFromBytes(bWasm, bZkey, bVkey)
+}
+
+// NewProverFromBytes returns a Prover for the circuit artifacts provided, for
+// example embedded in the binary. The verification key is optional.
+func NewProverFromBytes(wasm, zkey, vkey []byte) (*Prover, error) {
+	if len(zkey) == 0 {
+		return nil, fmt.Errorf("empty proving key")
+	}
+	if len(vkey) > 0 && !json.Valid(vkey) {
+		return nil, fmt.Errorf("invalid verification key")
+	}
+	calc, err := witness.NewCircom2WitnessCalculator(wasm, true)
+	if err != nil {
+		return nil, fmt.Errorf("error loading circuit wasm: %w", err)
+	}
+	return &Prover{
+		calc: calc,
+		zkey: zkey,
+		vkey: vkey,
+	}, nil
+}
+
+// Prove calculates the witness of the JSON encoded circuit inputs provided
+// and generates a proof of it.
+func (p *Prover) Prove(inputs []byte) (*Proof, error) {
+	finalInputs, err := witness.ParseInputs(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing inputs: %w", err)
+	}
+	w, err := p.witness(finalInputs)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating witness: %w", err)
+	}
+	zkProof, err := prover.Groth16Prover(p.zkey, w)
+	if err != nil {
+		return nil, fmt.Errorf("error generating proof: %w", err)
+	}
+	return NewProofFromZKProof(zkProof)
+}
+
+// witness calculates the witness of the inputs provided, it is the only
+// operation of the Prover that can not run concurrently.
+func (p *Prover) witness(inputs map[string]interface{}) ([]byte, error) {
+	p.calcMtx.Lock()
+	defer p.calcMtx.Unlock()
+	if p.calc == nil {
+		return nil, fmt.Errorf("prover closed")
+	}
+	return p.calc.CalculateWTNSBin(inputs, true)
+}
+
+// Verify checks the proof provided with the verification key of the Prover.
+func (p *Prover) Verify(proof *Proof) error {
+	if len(p.vkey) == 0 {
+		return fmt.Errorf("no verification key loaded")
+	}
+	return VerifyProof(proof, p.vkey)
+}
+
+// Close releases the resources of the witness calculator, the Prover can not
+// generate proofs after it.
+func (p *Prover) Close() {
+	p.calcMtx.Lock()
+	defer p.calcMtx.Unlock()
+	if p.calc != nil {
+		p.calc.Close()
+		p.calc = nil
+	}
+}
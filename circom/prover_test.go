@@ -0,0 +1,76 @@
+package circom
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestProver(t *testing.T) {
+	var (
+		wasmFile = "./artifacts/ballot_protocol_test.wasm"
+		zkeyFile = "./artifacts/ballot_protocol_test_pkey.zkey"
+		vkeyFile = "./artifacts/ballot_protocol_test_vkey.json"
+	)
+	for _, file := range []string{wasmFile, zkeyFile, vkeyFile} {
+		if _, err := os.Stat(file); err != nil {
+			t.Skipf("Circuit artifacts not found: %v\n", err)
+		}
+	}
+	p, err := NewProver(wasmFile, zkeyFile, vkeyFile)
+	if err != nil {
+		t.Fatalf("Error loading prover: %v\n", err)
+	}
+	defer p.Close()
+	// same inputs than TestBallotProtocol
+	bInputs, _ := json.Marshal(map[string]any{
+		"fields":           []string{"1", "2", "3", "0", "0"},
+		"max_count":        "3",
+		"force_uniqueness": "1",
+		"max_value":        "4",
+		"min_value":        "0",
+		"max_total_cost":   "15",
+		"min_total_cost":   "13",
+		"cost_exp":         "2",
+	})
+	// prove and verify concurrently with the same prover
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			proof, err := p.Prove(bInputs)
+			if err != nil {
+				t.Errorf("Error generating proof: %v\n", err)
+				return
+			}
+			if err := p.Verify(proof); err != nil {
+				t.Errorf("Error verifying proof: %v\n", err)
+			}
+		}()
+	}
+	wg.Wait()
+	// a tampered proof must fail
+	proof, err := p.Prove(bInputs)
+	if err != nil {
+		t.Fatalf("Error generating proof: %v\n", err)
+	}
+	proof.PublicSignals[0] = big.NewInt(1)
+	if err := p.Verify(proof); err == nil {
+		t.Error("Expected error verifying a tampered proof")
+	}
+}
+
+func TestNewProverFromBytes(t *testing.T) {
+	if _, err := NewProverFromBytes([]byte{0}, nil, nil); err == nil {
+		t.Error("Expected error loading an empty proving key")
+	}
+	if _, err := NewProverFromBytes([]byte{0}, []byte{0}, []byte("{")); err == nil {
+		t.Error("Expected error loading an invalid verification key")
+	}
+	if _, err := NewProverFromBytes([]byte{0}, []byte{0}, nil); err == nil {
+		t.Error("Expected error loading an invalid wasm")
+	}
+}
@@ -1,32 +1,13 @@
 package circom
 
 import (
-	"encoding/json"
 	"fmt"
 	"math/big"
-	"os"
 
-	"github.com/iden3/go-rapidsnark/prover"
-	"github.com/iden3/go-rapidsnark/types"
 	"github.com/iden3/go-rapidsnark/verifier"
-	"github.com/iden3/go-rapidsnark/witness"
 	"github.com/niclabs/tcpaillier"
 )
 
-type ProofData struct {
-	A []string   `json:"pi_a"`
-	B [][]string `json:"pi_b"`
-	C []string   `json:"pi_c"`
-}
-
-const (
-	// paillier parameters
-	bitSize = 64
-	s       = uint8(1)
-	l       = uint8(5) // number of shares
-	k       = uint8(3) // threshold
-)
-
 // BigIntToArray converts a big.Int into an array of k big.Int elements, it is
 // the go implementation of the javascript function:
 //
@@ -60,6 +41,41 @@ func BigIntToArray(n int, k int, x *big.Int) []*big.Int {
 	return ret
 }
 
+// BigIntToArrayChecked converts a big.Int into an array of k limbs of n bits
+// like BigIntToArray, but it returns an error if x is negative or does not
+// fit in n*k bits instead of truncating it.
+func BigIntToArrayChecked(n int, k int, x *big.Int) ([]*big.Int, error) {
+	if n <= 0 || k <= 0 {
+		return nil, fmt.Errorf("invalid number of limbs %d or limb size %d", k, n)
+	}
+	if x == nil || x.Sign() < 0 {
+		return nil, fmt.Errorf("value must be positive")
+	}
+	if x.BitLen() > n*k {
+		return nil, fmt.Errorf("value has %d bits and does not fit in %d limbs of %d bits", x.BitLen(), k, n)
+	}
+	return BigIntToArray(n, k, x), nil
+}
+
+// ArrayToBigInt converts an array of limbs of n bits, starting from the least
+// significant one, into a big.Int. It is the inverse of BigIntToArray and
+// returns an error if any limb is not between 0 (inclusive) and 2^n
+// (exclusive).
+func ArrayToBigInt(n int, arr []*big.Int) (*big.Int, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid limb size %d", n)
+	}
+	x := new(big.Int)
+	for i := len(arr) - 1; i >= 0; i-- {
+		if arr[i] == nil || arr[i].Sign() < 0 || arr[i].BitLen() > n {
+			return nil, fmt.Errorf("limb %d is not a number of %d bits", i, n)
+		}
+		x.Lsh(x, uint(n))
+		x.Add(x, arr[i])
+	}
+	return x, nil
+}
+
 // BigIntArrayToStringArray converts an array of big.Int into an array of strings
 func BigIntArrayToStringArray(arr []*big.Int) []string {
 	ret := make([]string, len(arr))
@@ -81,77 +97,101 @@ func IntArrayToStringArray(arr []int, n int) []string {
 	return strArr
 }
 
-func CompileAndGenerateProof(inputs []byte, wasmFile, zkeyFile string) (string, string, error) {
-	finalInputs, err := witness.ParseInputs(inputs)
-	if err != nil {
-		return "", "", err
-	}
-	// read wasm file
-	bWasm, err := os.ReadFile(wasmFile)
-	if err != nil {
-		return "", "", err
-	}
-	// read zkey file
-	bZkey, err := os.ReadFile(zkeyFile)
-	if err != nil {
-		return "", "", err
-	}
-	// instance witness calculator
-	calc, err := witness.NewCircom2WitnessCalculator(bWasm, true)
+// CompileAndGenerateProof calculates the witness of the JSON encoded inputs
+// provided with the circuit wasm file and generates a proof of it with the
+// proving key file. Use a Prover to generate many proofs of the same circuit.
+func CompileAndGenerateProof(inputs []byte, wasmFile, zkeyFile string) (*Proof, error) {
+	p, err := NewProver(wasmFile, zkeyFile, "")
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
-	// calculate witness
-	w, err := calc.CalculateWTNSBin(finalInputs, true)
-	if err != nil {
-		return "", "", err
+	defer p.Close()
+	return p.Prove(inputs)
+}
+
+// VerifyProof checks the proof provided with the JSON encoded verification
+// key.
+func VerifyProof(proof *Proof, vkey []byte) error {
+	if proof == nil {
+		return fmt.Errorf("nil proof")
 	}
-	// generate proof
-	return prover.Groth16ProverRaw(bZkey, w)
+	return verifier.VerifyGroth16(*proof.ZKProof(), vkey)
+}
+
+// PaillierInputs contains the inputs of the EncryptWithPaillier circuit
+// template derived from the public key and the encryption, every big integer
+// is split into limbs with BigIntToArray.
+type PaillierInputs struct {
+	NPlusOne    []string `json:"n_plus_one"`
+	RToNToS     []string `json:"r_to_n_to_s"`
+	NToSPlusOne []string `json:"n_to_s_plus_one"`
+	Ciphertext  []string `json:"ciphertext"`
 }
 
-func VerifyProof(proofData, pubSignals string, vkey []byte) error {
-	data := ProofData{}
-	if err := json.Unmarshal([]byte(proofData), &data); err != nil {
-		return err
+// PaillierEncryption contains the result of encrypting a message with
+// EncryptWithPaillier: the ciphertext, the random number r used, the
+// precomputed r^(n^s) mod n^(s+1) and the circuit inputs.
+type PaillierEncryption struct {
+	Ciphertext *big.Int
+	R          *big.Int
+	RToNToS    *big.Int
+	Inputs     *PaillierInputs
+}
+
+// EncryptWithPaillier encrypts the raw message provided with the public key
+// and the random number r provided. If r is nil, a random one is generated.
+// The circuit inputs are split into nLimbs limbs of lSize bits, so n^(s+1)
+// must fit in lSize*nLimbs bits. The message must fit in the
+// CircuitPlaintextBits supported by the circuit.
+func EncryptWithPaillier(pk *tcpaillier.PubKey, raw, r *big.Int, lSize, nLimbs int) (*PaillierEncryption, error) {
+	if pk == nil || pk.N == nil {
+		return nil, fmt.Errorf("public key is required")
 	}
-	signals := []string{}
-	if err := json.Unmarshal([]byte(pubSignals), &signals); err != nil {
-		return err
+	// get the cached constant values
+	cv := pk.Cache()
+	if raw == nil || raw.Sign() < 0 || raw.Cmp(cv.NToS) >= 0 {
+		return nil, fmt.Errorf("message must be between 0 (inclusive) and n^s (exclusive)")
 	}
-	proof := types.ZKProof{
-		Proof: &types.ProofData{
-			A: data.A,
-			B: data.B,
-			C: data.C,
-		},
-		PubSignals: signals,
+	if raw.BitLen() > CircuitPlaintextBits {
+		return nil, fmt.Errorf("message has %d bits but the circuit supports up to %d", raw.BitLen(), CircuitPlaintextBits)
 	}
-	return verifier.VerifyGroth16(proof, vkey)
-}
-
-func EncryptWithPaillier(raw *big.Int) (*tcpaillier.PubKey, *big.Int, *big.Int, error) {
-	// generate the public key
-	_, pk, err := tcpaillier.NewKey(bitSize, s, l, k)
+	// every input is lower than n^(s+1), so if it fits the rest fit too
+	nToSPlusOneLimbs, err := BigIntToArrayChecked(lSize, nLimbs, cv.NToSPlusOne)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, fmt.Errorf("n^(s+1) does not fit in the circuit limbs: %w", err)
 	}
-	// get a random mod
-	rnd, err := pk.RandomModNToSPlusOneStar()
-	if err != nil {
-		return nil, nil, nil, err
+	if r == nil {
+		if r, err = pk.RandomModNToSPlusOneStar(); err != nil {
+			return nil, err
+		}
+	} else if r.Sign() <= 0 || r.Cmp(cv.NToSPlusOne) >= 0 {
+		return nil, fmt.Errorf("r must be between 1 (inclusive) and n^(s+1) (exclusive)")
 	}
-	// encrypt with rnd
-	c, err := pk.EncryptFixed(raw, rnd)
+	// encrypt with r
+	c, err := pk.EncryptFixed(raw, r)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
-	return pk, rnd, c, nil
+	rToNToS := new(big.Int).Exp(r, cv.NToS, cv.NToSPlusOne)
+	return &PaillierEncryption{
+		Ciphertext: c,
+		R:          r,
+		RToNToS:    rToNToS,
+		Inputs: &PaillierInputs{
+			NPlusOne:    BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, cv.NPlusOne)),
+			RToNToS:     BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, rToNToS)),
+			NToSPlusOne: BigIntArrayToStringArray(nToSPlusOneLimbs),
+			Ciphertext:  BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, c)),
+		},
+	}, nil
 }
 
-// BallotConfig holds the configuration for the ballot protocol
+// BallotConfig holds the configuration for the ballot protocol. MaxValue is
+// the maximum value that a single field can take, it is only required to
+// decode aggregated ballots.
 type BallotConfig struct {
 	MaxCount int
+	MaxValue int
 	Base     int
 }
 
@@ -176,3 +216,64 @@ func EncodeBallot(ballot []int, config BallotConfig) *big.Int {
 	}
 	return encoded
 }
+
+// DecodeBallot decodes a ballot encoded with EncodeBallot into its fields. It
+// returns an error if the encoded value does not fit in config.MaxCount digits
+// of config.Base.
+func DecodeBallot(encoded *big.Int, config BallotConfig) ([]*big.Int, error) {
+	if config.MaxCount <= 0 {
+		return nil, fmt.Errorf("max count must be greater than 0, got %d", config.MaxCount)
+	}
+	if config.Base <= 1 {
+		return nil, fmt.Errorf("base must be greater than 1, got %d", config.Base)
+	}
+	if encoded == nil || encoded.Sign() < 0 {
+		return nil, fmt.Errorf("encoded ballot must be positive")
+	}
+	base := big.NewInt(int64(config.Base))
+	rest := new(big.Int).Set(encoded)
+	fields := make([]*big.Int, config.MaxCount)
+	// the first field is the most significant digit
+	for i := config.MaxCount - 1; i >= 0; i-- {
+		fields[i] = new(big.Int)
+		rest.QuoRem(rest, base, fields[i])
+	}
+	if rest.Sign() != 0 {
+		return nil, fmt.Errorf("encoded ballot exceeds %d fields in base %d", config.MaxCount, config.Base)
+	}
+	return fields, nil
+}
+
+// DecodeTally decodes the sum of the encoded ballots of the number of voters
+// provided into the total of each field. Every total can reach
+// voters * config.MaxValue, so if that value is not lower than config.Base a
+// digit could have carried into the next position and the result is not
+// reliable, in that case an error is returned. Zero voters is allowed, for an
+// empty tally, and then every total must be zero.
+func DecodeTally(encoded *big.Int, config BallotConfig, voters int) ([]*big.Int, error) {
+	if voters < 0 {
+		return nil, fmt.Errorf("number of voters must not be negative, got %d", voters)
+	}
+	if config.MaxValue <= 0 {
+		return nil, fmt.Errorf("max value must be greater than 0, got %d", config.MaxValue)
+	}
+	// maximum total of a single field
+	maxTotal := new(big.Int).Mul(big.NewInt(int64(voters)), big.NewInt(int64(config.MaxValue)))
+	if maxTotal.Cmp(big.NewInt(int64(config.Base))) >= 0 {
+		return nil, fmt.Errorf("base %d is too small for %d voters with max value %d, fields could overflow",
+			config.Base, voters, config.MaxValue)
+	}
+	fields, err := DecodeBallot(encoded, config)
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range fields {
+		if voters == 0 && field.Sign() != 0 {
+			return nil, fmt.Errorf("field %d total %s must be zero without voters", i, field)
+		}
+		if field.Cmp(maxTotal) > 0 {
+			return nil, fmt.Errorf("field %d total %s exceeds the maximum %s", i, field, maxTotal)
+		}
+	}
+	return fields, nil
+}
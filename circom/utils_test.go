@@ -0,0 +1,134 @@
+package circom
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+func TestDecodeBallot(t *testing.T) {
+	config := BallotConfig{MaxCount: 5, Base: 100}
+	fields := []int{5, 1, 4, 3, 0}
+	decoded, err := DecodeBallot(EncodeBallot(fields, config), config)
+	if err != nil {
+		t.Fatalf("Error decoding ballot: %v\n", err)
+	}
+	for i, field := range fields {
+		if decoded[i].Int64() != int64(field) {
+			t.Errorf("Unexpected field %d: expected %d, got %s\n", i, field, decoded[i])
+		}
+	}
+	// a value with more digits than max count must fail
+	if _, err := DecodeBallot(powBigInt(config.Base, config.MaxCount), config); err == nil {
+		t.Error("Expected error decoding an oversized ballot")
+	}
+	if _, err := DecodeBallot(nil, config); err == nil {
+		t.Error("Expected error decoding a nil ballot")
+	}
+}
+
+func TestDecodeTally(t *testing.T) {
+	config := BallotConfig{MaxCount: 3, MaxValue: 9, Base: 100}
+	ballots := [][]int{{9, 0, 1}, {9, 3, 2}, {9, 9, 0}}
+	sum := big.NewInt(0)
+	for _, ballot := range ballots {
+		sum.Add(sum, EncodeBallot(ballot, config))
+	}
+	totals, err := DecodeTally(sum, config, len(ballots))
+	if err != nil {
+		t.Fatalf("Error decoding tally: %v\n", err)
+	}
+	for i, expected := range []int64{27, 12, 3} {
+		if totals[i].Int64() != expected {
+			t.Errorf("Unexpected total %d: expected %d, got %s\n", i, expected, totals[i])
+		}
+	}
+	// 12 voters with max value 9 could reach 108 in a single field, which
+	// carries into the next position with base 100
+	if _, err := DecodeTally(sum, config, 12); err == nil {
+		t.Error("Expected overflow error decoding tally")
+	}
+	// a field total over voters * max value is detected
+	if _, err := DecodeTally(sum, config, 2); err == nil {
+		t.Error("Expected error decoding a field over its maximum")
+	}
+	// an empty tally decodes to zero totals
+	totals, err = DecodeTally(big.NewInt(0), config, 0)
+	if err != nil {
+		t.Fatalf("Error decoding an empty tally: %v\n", err)
+	}
+	for i, total := range totals {
+		if total.Sign() != 0 {
+			t.Errorf("Unexpected total for field %d of an empty tally: %s\n", i, total)
+		}
+	}
+	for _, voters := range []int{0, -1} {
+		if _, err := DecodeTally(sum, config, voters); err == nil {
+			t.Errorf("Expected error decoding the tally with %d voters\n", voters)
+		}
+	}
+}
+
+func TestEncryptWithPaillier(t *testing.T) {
+	_, pk, err := tcpaillier.NewKey(256, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	raw := big.NewInt(102030405)
+	r, err := pk.RandomModNToSPlusOneStar()
+	if err != nil {
+		t.Fatalf("Error generating r: %v\n", err)
+	}
+	enc, err := EncryptWithPaillier(pk, raw, r, 32, 16)
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	// the ciphertext is bound to the key and r provided
+	expected, _ := pk.EncryptFixed(raw, r)
+	if enc.Ciphertext.Cmp(expected) != 0 || enc.R.Cmp(r) != 0 {
+		t.Error("Ciphertext does not match the key and r provided")
+	}
+	if len(enc.Inputs.Ciphertext) != 16 || enc.Inputs.Ciphertext[0] != BigIntToArray(32, 16, expected)[0].String() {
+		t.Errorf("Unexpected ciphertext limbs: %v\n", enc.Inputs.Ciphertext)
+	}
+	// n^2 has 512 bits and does not fit in 8 limbs of 32 bits
+	if _, err := EncryptWithPaillier(pk, raw, r, 32, 8); err == nil {
+		t.Error("Expected error splitting n^2 in too few limbs")
+	}
+	// the message does not fit in the circuit
+	if _, err := EncryptWithPaillier(pk, new(big.Int).Lsh(big.NewInt(1), CircuitPlaintextBits), r, 32, 16); err == nil {
+		t.Error("Expected error encrypting a message too big for the circuit")
+	}
+}
+
+func TestArrayToBigInt(t *testing.T) {
+	x, _ := new(big.Int).SetString("123456789012345678901234567890123456789", 10)
+	limbs, err := BigIntToArrayChecked(32, 4, x)
+	if err != nil {
+		t.Fatalf("Error splitting: %v\n", err)
+	}
+	joined, err := ArrayToBigInt(32, limbs)
+	if err != nil {
+		t.Fatalf("Error joining: %v\n", err)
+	}
+	if joined.Cmp(x) != 0 {
+		t.Errorf("Unexpected value: expected %s, got %s\n", x, joined)
+	}
+	// x has 127 bits and BigIntToArray truncates it silently
+	if _, err := BigIntToArrayChecked(32, 3, x); err == nil {
+		t.Error("Expected error splitting a value that does not fit")
+	}
+	if _, err := BigIntToArrayChecked(32, 4, big.NewInt(-1)); err == nil {
+		t.Error("Expected error splitting a negative value")
+	}
+	// limbs out of range
+	limbs[1] = new(big.Int).Lsh(big.NewInt(1), 32)
+	if _, err := ArrayToBigInt(32, limbs); err == nil {
+		t.Error("Expected error joining a limb of more than 32 bits")
+	}
+	limbs[1] = big.NewInt(-1)
+	if _, err := ArrayToBigInt(32, limbs); err == nil {
+		t.Error("Expected error joining a negative limb")
+	}
+}
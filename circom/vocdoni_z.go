@@ -0,0 +1,302 @@
+package circom
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/nullifier"
+)
+
+// VocdoniZCircuit holds the parameters that the VocdoniZ circuit has been
+// compiled with.
+type VocdoniZCircuit struct {
+	NFields int // n_fields
+	LSize   int // l_size
+	NLimbs  int // n_limbs
+}
+
+// BallotProtocolParams holds the ballot protocol parameters of an election,
+// that are the public inputs of the VocdoniZ circuit besides the encryption
+// key, the ciphertext and the nullifier.
+type BallotProtocolParams struct {
+	MaxCount        int
+	ForceUniqueness bool
+	MaxValue        int
+	MinValue        int
+	MaxTotalCost    int
+	MinTotalCost    int
+	CostExp         int
+	CostFromWeight  bool
+	Weight          int
+	Base            int
+}
+
+// BallotConfig returns the configuration to encode and decode the ballots of
+// the ballot protocol parameters.
+func (p BallotProtocolParams) BallotConfig() BallotConfig {
+	return BallotConfig{
+		MaxCount: p.MaxCount,
+		MaxValue: p.MaxValue,
+		Base:     p.Base,
+	}
+}
+
+// VoterSecret holds the values of the voter used to derive the commitment
+// and the nullifier of the ballot.
+type VoterSecret struct {
+	Address   nullifier.Address
+	ProcessID nullifier.ProcessID
+	Secret    nullifier.Secret
+}
+
+// VocdoniZInputs holds the typed inputs of the VocdoniZ circuit. It is
+// encoded to the JSON expected by witness.ParseInputs after validating every
+// value against the circuit parameters.
+type VocdoniZInputs struct {
+	Circuit    VocdoniZCircuit
+	Fields     []int
+	Params     BallotProtocolParams
+	Encryption *PaillierEncryption
+	Nullifier  *big.Int
+	Commitment *big.Int
+	Secret     *big.Int
+}
+
+// vocdoniZInputsJSON is the JSON representation of the VocdoniZ inputs, with
+// the names of the circuit signals.
+type vocdoniZInputsJSON struct {
+	Fields          []string `json:"fields"`
+	MaxCount        string   `json:"max_count"`
+	ForceUniqueness string   `json:"force_uniqueness"`
+	MaxValue        string   `json:"max_value"`
+	MinValue        string   `json:"min_value"`
+	MaxTotalCost    string   `json:"max_total_cost"`
+	MinTotalCost    string   `json:"min_total_cost"`
+	CostExp         string   `json:"cost_exp"`
+	CostFromWeight  string   `json:"cost_from_weight"`
+	Weight          string   `json:"weight"`
+	Base            string   `json:"base"`
+	*PaillierInputs
+	Nullifier  string `json:"nullifier"`
+	Commitment string `json:"commitment"`
+	Secret     string `json:"secret"`
+}
+
+// NewVocdoniZInputs builds the inputs of the VocdoniZ circuit for the ballot
+// fields provided. It checks the ballot with ValidateBallot, encodes the
+// first params.MaxCount fields with params.Base, encrypts the result with the
+// public key and the random r provided (a random one if it is nil), and
+// derives the commitment and the nullifier from the voter secret. The
+// resulting inputs are validated.
+func NewVocdoniZInputs(circuit VocdoniZCircuit, fields []int, params BallotProtocolParams,
+	pk *tcpaillier.PubKey, r *big.Int, voter VoterSecret,
+) (*VocdoniZInputs, error) {
+	if len(fields) > circuit.NFields {
+		return nil, fmt.Errorf("expected up to %d fields, got %d", circuit.NFields, len(fields))
+	}
+	// the circuit receives n_fields fields, the rest are zero
+	padded := make([]int, circuit.NFields)
+	copy(padded, fields)
+	fields = padded
+	if err := ValidateBallot(fields, params); err != nil {
+		return nil, err
+	}
+	encodedBallot := EncodeBallot(fields[:params.MaxCount], params.BallotConfig())
+	enc, err := EncryptWithPaillier(pk, encodedBallot, r, circuit.LSize, circuit.NLimbs)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting ballot: %w", err)
+	}
+	commitment, nullifierHash, err := nullifier.Derive(voter.Address, voter.ProcessID, voter.Secret)
+	if err != nil {
+		return nil, err
+	}
+	inputs := &VocdoniZInputs{
+		Circuit:    circuit,
+		Fields:     fields,
+		Params:     params,
+		Encryption: enc,
+		Nullifier:  nullifierHash,
+		Commitment: commitment,
+		Secret:     voter.Secret.FF(),
+	}
+	if err := inputs.Validate(); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// Validate checks that every input fits in the circuit parameters.
+func (in *VocdoniZInputs) Validate() error {
+	c := in.Circuit
+	if c.NFields <= 0 || c.LSize <= 0 || c.NLimbs <= 0 {
+		return fmt.Errorf("invalid circuit parameters %+v", c)
+	}
+	if len(in.Fields) > c.NFields {
+		return fmt.Errorf("expected up to %d fields, got %d", c.NFields, len(in.Fields))
+	}
+	for i, field := range in.Fields {
+		if field < 0 {
+			return fmt.Errorf("field %d must be positive, got %d", i, field)
+		}
+	}
+	p := in.Params
+	if p.MaxCount < 0 || p.MaxCount > c.NFields {
+		return fmt.Errorf("max count must be between 0 and %d, got %d", c.NFields, p.MaxCount)
+	}
+	for name, value := range map[string]int{
+		"max value":      p.MaxValue,
+		"min value":      p.MinValue,
+		"max total cost": p.MaxTotalCost,
+		"min total cost": p.MinTotalCost,
+		"cost exponent":  p.CostExp,
+		"weight":         p.Weight,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%s must be positive, got %d", name, value)
+		}
+	}
+	if p.Base <= 1 {
+		return fmt.Errorf("base must be greater than 1, got %d", p.Base)
+	}
+	if in.Encryption == nil || in.Encryption.Inputs == nil {
+		return fmt.Errorf("missing encryption inputs")
+	}
+	enc := in.Encryption.Inputs
+	for name, limbs := range map[string][]string{
+		"n_plus_one":      enc.NPlusOne,
+		"r_to_n_to_s":     enc.RToNToS,
+		"n_to_s_plus_one": enc.NToSPlusOne,
+		"ciphertext":      enc.Ciphertext,
+	} {
+		if len(limbs) != c.NLimbs {
+			return fmt.Errorf("%s must have %d limbs, got %d", name, c.NLimbs, len(limbs))
+		}
+		arr := make([]*big.Int, len(limbs))
+		for i, limb := range limbs {
+			arr[i], _ = new(big.Int).SetString(limb, 10)
+		}
+		if _, err := ArrayToBigInt(c.LSize, arr); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	for name, value := range map[string]*big.Int{
+		"nullifier":  in.Nullifier,
+		"commitment": in.Commitment,
+		"secret":     in.Secret,
+	} {
+		if value == nil || value.Sign() < 0 || value.Cmp(constants.Q) >= 0 {
+			return fmt.Errorf("%s must be an element of the circuit field", name)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON validates the inputs and encodes them as the JSON expected by
+// witness.ParseInputs.
+func (in *VocdoniZInputs) MarshalJSON() ([]byte, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&vocdoniZInputsJSON{
+		Fields:          IntArrayToStringArray(in.Fields, in.Circuit.NFields),
+		MaxCount:        fmt.Sprint(in.Params.MaxCount),
+		ForceUniqueness: boolToString(in.Params.ForceUniqueness),
+		MaxValue:        fmt.Sprint(in.Params.MaxValue),
+		MinValue:        fmt.Sprint(in.Params.MinValue),
+		MaxTotalCost:    fmt.Sprint(in.Params.MaxTotalCost),
+		MinTotalCost:    fmt.Sprint(in.Params.MinTotalCost),
+		CostExp:         fmt.Sprint(in.Params.CostExp),
+		CostFromWeight:  boolToString(in.Params.CostFromWeight),
+		Weight:          fmt.Sprint(in.Params.Weight),
+		Base:            fmt.Sprint(in.Params.Base),
+		PaillierInputs:  in.Encryption.Inputs,
+		Nullifier:       in.Nullifier.String(),
+		Commitment:      in.Commitment.String(),
+		Secret:          in.Secret.String(),
+	})
+}
+
+// boolToString returns the circuit representation of a boolean, there is no
+// boolean type in circom.
+func boolToString(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// VocdoniZPublicSignals holds the public signals of a VocdoniZ proof by name,
+// with the limbs of the public key components and the ciphertext reassembled.
+type VocdoniZPublicSignals struct {
+	Params      BallotProtocolParams
+	NPlusOne    *big.Int
+	NToSPlusOne *big.Int
+	Ciphertext  *big.Int
+	Nullifier   *big.Int
+}
+
+// NumPublicSignals returns the number of public signals of the circuit: the
+// ten ballot protocol params and the base, the limbs of n_plus_one,
+// n_to_s_plus_one and ciphertext, and the nullifier.
+func (c VocdoniZCircuit) NumPublicSignals() int {
+	return 10 + 3*c.NLimbs + 1
+}
+
+// DecodePublicSignals maps the public signals of a VocdoniZ proof, in the
+// order they are declared in the circuit, to their names. The ballot
+// protocol params must fit in an int and the boolean ones must be 0 or 1.
+func (c VocdoniZCircuit) DecodePublicSignals(signals []*big.Int) (*VocdoniZPublicSignals, error) {
+	if len(signals) != c.NumPublicSignals() {
+		return nil, fmt.Errorf("expected %d public signals, got %d", c.NumPublicSignals(), len(signals))
+	}
+	for i, signal := range signals {
+		if signal == nil || signal.Sign() < 0 {
+			return nil, fmt.Errorf("invalid public signal %d", i)
+		}
+	}
+	names := []string{"max_count", "force_uniqueness", "max_value", "min_value", "max_total_cost",
+		"min_total_cost", "cost_exp", "cost_from_weight", "weight", "base"}
+	params := make([]int, len(names))
+	for i, name := range names {
+		if !signals[i].IsInt64() || signals[i].Int64() > math.MaxInt {
+			return nil, fmt.Errorf("%s does not fit in an int: %s", name, signals[i])
+		}
+		params[i] = int(signals[i].Int64())
+	}
+	for _, i := range []int{1, 7} {
+		if params[i] > 1 {
+			return nil, fmt.Errorf("%s must be 0 or 1, got %d", names[i], params[i])
+		}
+	}
+	// the limbs of every big integer follow the params
+	limbs := signals[len(names):]
+	values := make([]*big.Int, 3)
+	for i, name := range []string{"n_plus_one", "n_to_s_plus_one", "ciphertext"} {
+		var err error
+		if values[i], err = ArrayToBigInt(c.LSize, limbs[i*c.NLimbs:(i+1)*c.NLimbs]); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return &VocdoniZPublicSignals{
+		Params: BallotProtocolParams{
+			MaxCount:        params[0],
+			ForceUniqueness: params[1] == 1,
+			MaxValue:        params[2],
+			MinValue:        params[3],
+			MaxTotalCost:    params[4],
+			MinTotalCost:    params[5],
+			CostExp:         params[6],
+			CostFromWeight:  params[7] == 1,
+			Weight:          params[8],
+			Base:            params[9],
+		},
+		NPlusOne:    values[0],
+		NToSPlusOne: values[1],
+		Ciphertext:  values[2],
+		Nullifier:   new(big.Int).Set(limbs[3*c.NLimbs]),
+	}, nil
+}
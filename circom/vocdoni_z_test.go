@@ -10,14 +10,15 @@ import (
 	"os"
 	"testing"
 
-	"github.com/iden3/go-iden3-crypto/poseidon"
-	"go.vocdoni.io/dvote/util"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-rapidsnark/witness"
+	"github.com/niclabs/tcpaillier"
 )
 
 func TestVocdoniZ(t *testing.T) {
 	var (
 		fields       = []int{3, 5, 2, 4, 1}
-		n_fields     = 5
+		nFields      = 5
 		maxCount     = 5
 		maxValue     = 16 + 1
 		minValue     = 0
@@ -26,6 +27,12 @@ func TestVocdoniZ(t *testing.T) {
 		address, _   = hex.DecodeString("0x6Db989fbe7b1308cc59A27f021e2E3de9422CF0A")
 		processID, _ = hex.DecodeString("0xf16236a51F11c0Bf97180eB16694e3A345E42506")
 		secret, _    = hex.DecodeString("super-secret-mnemonic-phrase")
+		// paillier parameters, n^2 must fit in nLimbs*lSize bits and n must
+		// be greater than the encoded ballot (~98 bits)
+		bitSize = 128
+		s       = uint8(1)
+		l       = uint8(5) // number of shares
+		k       = uint8(3) // threshold
 		// circuit parameters
 		lSize  = 32
 		nLimbs = 8
@@ -34,78 +41,213 @@ func TestVocdoniZ(t *testing.T) {
 		zkeyFile = "./artifacts/vocdoni_z_pkey.zkey"
 		vkeyFile = "./artifacts/vocdoni_z_vkey.json"
 	)
-	encodedBallot := EncodeBallot(fields, BallotConfig{
-		MaxCount: maxCount,
-		Base:     base,
-	})
-	// encrypt with r
-	pk, rnd, c, err := EncryptWithPaillier(encodedBallot)
+	// generate the public key
+	_, pk, err := tcpaillier.NewKey(bitSize, s, l, k)
 	if err != nil {
-		log.Fatalf("Error encrypting: %v\n", err)
-		return
+		t.Fatalf("Error generating key: %v\n", err)
 	}
-	// get the cached constant values
-	cv := pk.Cache()
-	rToNToS := new(big.Int).Exp(rnd, cv.NToS, cv.NToSPlusOne)
-	// generate the nullifier
-	commitment, err := poseidon.Hash([]*big.Int{
-		util.BigToFF(new(big.Int).SetBytes(address)),
-		util.BigToFF(new(big.Int).SetBytes(processID)),
-		util.BigToFF(new(big.Int).SetBytes(secret)),
+	// build the circuit inputs encrypting with a random r
+	inputs, err := NewVocdoniZInputs(VocdoniZCircuit{
+		NFields: nFields,
+		LSize:   lSize,
+		NLimbs:  nLimbs,
+	}, fields, BallotProtocolParams{
+		MaxCount:     maxCount,
+		MaxValue:     maxValue,
+		MinValue:     minValue,
+		MaxTotalCost: int(math.Pow(float64(maxValue-1), float64(costExp))) * maxCount, // (maxValue-1)^costExp * maxCount
+		MinTotalCost: maxCount,
+		CostExp:      costExp,
+		Weight:       1,
+		Base:         base,
+	}, pk, nil, VoterSecret{
+		Address:   address,
+		ProcessID: processID,
+		Secret:    secret,
 	})
 	if err != nil {
-		log.Fatalf("Error hashing: %v\n", err)
-		return
+		t.Fatalf("Error building inputs: %v\n", err)
 	}
-	nullifier, err := poseidon.Hash([]*big.Int{
-		commitment,
-		util.BigToFF(new(big.Int).SetBytes(secret)),
-	})
+	log.Println("Commitment:", inputs.Commitment)
+	log.Println("Nullifier:", inputs.Nullifier)
+	bInputs, err := json.Marshal(inputs)
 	if err != nil {
-		log.Fatalf("Error hashing: %v\n", err)
-		return
+		t.Fatalf("Error encoding inputs: %v\n", err)
 	}
-	log.Println("Commitment:", commitment)
-	log.Println("Nullifier:", nullifier)
-	// circuit inputs
-	inputs := map[string]any{
-		"fields":           IntArrayToStringArray(fields, n_fields),
-		"max_count":        fmt.Sprint(maxCount),
-		"force_uniqueness": "0",
-		"max_value":        fmt.Sprint(maxValue),
-		"min_value":        fmt.Sprint(minValue),
-		"cost_exp":         fmt.Sprint(costExp),
-		"max_total_cost":   fmt.Sprint(int(math.Pow(float64(maxValue-1), float64(costExp))) * maxCount), // (maxValue-1)^costExp * maxCount
-		"min_total_cost":   fmt.Sprint(maxCount),
-		"cost_from_weight": "0",
-		"weight":           "1",
-		"base":             fmt.Sprint(base),
-		"n_plus_one":       BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, cv.NPlusOne)),
-		"r_to_n_to_s":      BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, rToNToS)),
-		"n_to_s_plus_one":  BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, cv.NToSPlusOne)),
-		"ciphertext":       BigIntArrayToStringArray(BigIntToArray(lSize, nLimbs, c)),
-		"nullifier":        nullifier.String(),
-		"commitment":       commitment.String(),
-		"secret":           util.BigToFF(new(big.Int).SetBytes(secret)).String(),
-	}
-	bInputs, _ := json.Marshal(inputs)
 	t.Log("Inputs:", string(bInputs))
-	proofData, pubSignals, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
+	proof, err := CompileAndGenerateProof(bInputs, wasmFile, zkeyFile)
 	if err != nil {
 		t.Errorf("Error compiling and generating proof: %v\n", err)
 		return
 	}
-	log.Println("Proof data:", proofData)
-	log.Println("Public signals:", pubSignals)
+	log.Println("Proof data:", proof.A, proof.B, proof.C)
+	log.Println("Public signals:", proof.PublicSignals)
 	// read zkey file
 	vkey, err := os.ReadFile(vkeyFile)
 	if err != nil {
 		t.Errorf("Error reading zkey file: %v\n", err)
 		return
 	}
-	if err := VerifyProof(proofData, pubSignals, vkey); err != nil {
+	if err := VerifyProof(proof, vkey); err != nil {
 		t.Errorf("Error verifying proof: %v\n", err)
 		return
 	}
 	log.Println("Proof verified")
+	ciphertext, nullifier, err := VerifyBallot(proof, ElectionConfig{
+		Circuit:         inputs.Circuit,
+		VerificationKey: vkey,
+		Params:          inputs.Params,
+		PubKey:          pk,
+	})
+	if err != nil {
+		t.Fatalf("Error verifying ballot: %v\n", err)
+	}
+	if ciphertext.Cmp(inputs.Encryption.Ciphertext) != 0 || nullifier.Cmp(inputs.Nullifier) != 0 {
+		t.Error("Proof is not bound to the ciphertext and nullifier of the inputs")
+	}
+}
+
+func TestVocdoniZInputs(t *testing.T) {
+	circuit := VocdoniZCircuit{NFields: 5, LSize: 32, NLimbs: 8}
+	params := BallotProtocolParams{
+		MaxCount:     3,
+		MaxValue:     16,
+		MaxTotalCost: 3 * 16 * 16,
+		CostExp:      2,
+		Weight:       1,
+		Base:         16000001,
+	}
+	voter := VoterSecret{
+		Address:   []byte{0x01},
+		ProcessID: []byte{0x02},
+		Secret:    []byte("secret"),
+	}
+	_, pk, err := tcpaillier.NewKey(128, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	inputs, err := NewVocdoniZInputs(circuit, []int{3, 5, 2}, params, pk, nil, voter)
+	if err != nil {
+		t.Fatalf("Error building inputs: %v\n", err)
+	}
+	bInputs, err := json.Marshal(inputs)
+	if err != nil {
+		t.Fatalf("Error encoding inputs: %v\n", err)
+	}
+	parsed, err := witness.ParseInputs(bInputs)
+	if err != nil {
+		t.Fatalf("Error parsing inputs: %v\n", err)
+	}
+	for _, signal := range []string{"fields", "max_count", "force_uniqueness", "max_value", "min_value",
+		"max_total_cost", "min_total_cost", "cost_exp", "cost_from_weight", "weight", "base", "n_plus_one",
+		"r_to_n_to_s", "n_to_s_plus_one", "ciphertext", "nullifier", "commitment", "secret"} {
+		if _, ok := parsed[signal]; !ok {
+			t.Errorf("Missing signal %s\n", signal)
+		}
+	}
+	// the fields are padded to the circuit size
+	var encoded struct {
+		Fields []string `json:"fields"`
+	}
+	if err := json.Unmarshal(bInputs, &encoded); err != nil {
+		t.Fatalf("Error decoding inputs: %v\n", err)
+	}
+	if len(encoded.Fields) != circuit.NFields {
+		t.Errorf("Expected %d fields, got %d\n", circuit.NFields, len(encoded.Fields))
+	}
+	// malformed inputs must be rejected
+	for name, mutate := range map[string]func(*VocdoniZInputs){
+		"too many fields": func(in *VocdoniZInputs) { in.Fields = make([]int, circuit.NFields+1) },
+		"negative field":  func(in *VocdoniZInputs) { in.Fields = []int{-1} },
+		"bad max count":   func(in *VocdoniZInputs) { in.Params.MaxCount = circuit.NFields + 1 },
+		"bad base":        func(in *VocdoniZInputs) { in.Params.Base = 1 },
+		"missing limb":    func(in *VocdoniZInputs) { in.Encryption.Inputs.Ciphertext = in.Encryption.Inputs.Ciphertext[1:] },
+		"oversized limb":  func(in *VocdoniZInputs) { in.Encryption.Inputs.NPlusOne[0] = "4294967296" },
+		"non field value": func(in *VocdoniZInputs) { in.Nullifier = constants.Q },
+	} {
+		invalid, err := NewVocdoniZInputs(circuit, []int{3, 5, 2}, params, pk, nil, voter)
+		if err != nil {
+			t.Fatalf("Error building inputs: %v\n", err)
+		}
+		mutate(invalid)
+		if _, err := json.Marshal(invalid); err == nil {
+			t.Errorf("Expected error encoding inputs with %s\n", name)
+		}
+	}
+	// the key must fit in the circuit limbs
+	_, bigPK, err := tcpaillier.NewKey(256, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	if _, err := NewVocdoniZInputs(circuit, []int{3, 5, 2}, params, bigPK, nil, voter); err == nil {
+		t.Error("Expected error building inputs with a key too big for the circuit")
+	}
+}
+
+func TestVocdoniZPublicSignals(t *testing.T) {
+	circuit := VocdoniZCircuit{NFields: 5, LSize: 32, NLimbs: 8}
+	params := BallotProtocolParams{
+		MaxCount:        3,
+		ForceUniqueness: true,
+		MaxValue:        16,
+		MaxTotalCost:    3 * 16 * 16,
+		CostExp:         2,
+		Weight:          1,
+		Base:            16000001,
+	}
+	_, pk, err := tcpaillier.NewKey(128, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	inputs, err := NewVocdoniZInputs(circuit, []int{3, 5, 2}, params, pk, nil, VoterSecret{Secret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("Error building inputs: %v\n", err)
+	}
+	signals := testPublicSignals(inputs)
+	if len(signals) != 35 || circuit.NumPublicSignals() != 35 {
+		t.Fatalf("Unexpected number of public signals: %d\n", len(signals))
+	}
+	decoded, err := circuit.DecodePublicSignals(signals)
+	if err != nil {
+		t.Fatalf("Error decoding public signals: %v\n", err)
+	}
+	if decoded.Params != params {
+		t.Errorf("Unexpected params: %+v\n", decoded.Params)
+	}
+	cache := pk.Cache()
+	if decoded.NPlusOne.Cmp(cache.NPlusOne) != 0 || decoded.NToSPlusOne.Cmp(cache.NToSPlusOne) != 0 {
+		t.Error("Unexpected public key components")
+	}
+	if decoded.Ciphertext.Cmp(inputs.Encryption.Ciphertext) != 0 || decoded.Nullifier.Cmp(inputs.Nullifier) != 0 {
+		t.Error("Unexpected ciphertext or nullifier")
+	}
+	// wrong number of signals and non boolean flags
+	if _, err := circuit.DecodePublicSignals(signals[1:]); err == nil {
+		t.Error("Expected error decoding too few public signals")
+	}
+	signals[1] = big.NewInt(2)
+	if _, err := circuit.DecodePublicSignals(signals); err == nil {
+		t.Error("Expected error decoding a non boolean force_uniqueness")
+	}
+}
+
+// testPublicSignals returns the public signals of the inputs provided in the
+// order of the circuit, like a VocdoniZ proof of them.
+func testPublicSignals(inputs *VocdoniZInputs) []*big.Int {
+	p := inputs.Params
+	signals := []*big.Int{}
+	for _, value := range []string{fmt.Sprint(p.MaxCount), boolToString(p.ForceUniqueness), fmt.Sprint(p.MaxValue),
+		fmt.Sprint(p.MinValue), fmt.Sprint(p.MaxTotalCost), fmt.Sprint(p.MinTotalCost), fmt.Sprint(p.CostExp),
+		boolToString(p.CostFromWeight), fmt.Sprint(p.Weight), fmt.Sprint(p.Base)} {
+		x, _ := new(big.Int).SetString(value, 10)
+		signals = append(signals, x)
+	}
+	enc := inputs.Encryption.Inputs
+	for _, limbs := range [][]string{enc.NPlusOne, enc.NToSPlusOne, enc.Ciphertext} {
+		for _, limb := range limbs {
+			x, _ := new(big.Int).SetString(limb, 10)
+			signals = append(signals, x)
+		}
+	}
+	return append(signals, inputs.Nullifier)
 }
@@ -0,0 +1,325 @@
+// Command paillierctl runs the steps of a threshold Paillier ceremony: key
+// generation, encryption, homomorphic addition, partial decryption and
+// combination of decryption shares. Every value is read from and written to
+// JSON files (see the codec package), using "-" or an empty path for
+// stdin/stdout. Stdin can only be used for one input of a command.
+//
+// Usage:
+//
+//	paillierctl keygen [-bits 512] [-s 1] [-l 5] [-k 3] [-out .]
+//	paillierctl encrypt -pubkey pubkey.json [-out file] [value]
+//	paillierctl add -pubkey pubkey.json [-out file] ciphertext...
+//	paillierctl partial-decrypt -share share-1.json [-in file] [-out file]
+//	paillierctl combine -pubkey pubkey.json [-out file] share...
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/codec"
+)
+
+const usage = `usage: paillierctl <command> [flags] [args]
+
+commands:
+  keygen           generate a public key and one key share file per trustee
+  encrypt          encrypt a value (argument or stdin) with a public key
+  add              add homomorphically the ciphertexts provided
+  partial-decrypt  compute the decryption share of a ciphertext
+  combine          combine decryption shares to get the plaintext
+
+run 'paillierctl <command> -h' to get the flags of a command`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command in args reading from stdin and writing to stdout
+// when no files are provided.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "keygen":
+		return keygen(args, stdout)
+	case "encrypt":
+		return encrypt(args, stdin, stdout)
+	case "add":
+		return add(args, stdin, stdout)
+	case "partial-decrypt":
+		return partialDecrypt(args, stdin, stdout)
+	case "combine":
+		return combine(args, stdin, stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintln(stdout, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+}
+
+// keygen generates a new key and writes the public key to <out>/pubkey.json
+// and every key share to <out>/share-<index>.json.
+func keygen(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	bits := flags.Int("bits", 512, "size of the modulus n in bits")
+	s := flags.Uint("s", 1, "Damgård–Jurik exponent s")
+	l := flags.Uint("l", 5, "number of key shares (trustees)")
+	k := flags.Uint("k", 3, "number of shares required to decrypt (threshold)")
+	out := flags.String("out", ".", "output directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *s > 255 || *l > 255 || *k > 255 {
+		return fmt.Errorf("s, l and k must be lower than 256")
+	}
+	shares, pk, err := tcpaillier.NewKey(*bits, uint8(*s), uint8(*l), uint8(*k))
+	if err != nil {
+		return fmt.Errorf("error generating key: %w", err)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+	bPubKey, err := codec.MarshalPubKey(pk)
+	if err != nil {
+		return err
+	}
+	pubKeyFile := filepath.Join(*out, "pubkey.json")
+	if err := os.WriteFile(pubKeyFile, bPubKey, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, pubKeyFile)
+	for _, share := range shares {
+		bShare, err := codec.MarshalKeyShare(share)
+		if err != nil {
+			return err
+		}
+		shareFile := filepath.Join(*out, fmt.Sprintf("share-%d.json", share.Index))
+		// key shares are secret, only readable by the owner
+		if err := os.WriteFile(shareFile, bShare, 0o600); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, shareFile)
+	}
+	return nil
+}
+
+// encrypt encrypts the decimal value provided as argument, or read from stdin
+// if no argument is provided, and writes the ciphertext.
+func encrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	pubKeyFile := flags.String("pubkey", "", "public key file")
+	out := flags.String("out", "", "output ciphertext file (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *pubKeyFile == "-" && flags.NArg() == 0 {
+		return fmt.Errorf("stdin can not be read for both the public key and the value")
+	}
+	pk, err := readPubKey(*pubKeyFile, stdin)
+	if err != nil {
+		return err
+	}
+	var strValue string
+	switch flags.NArg() {
+	case 0:
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		strValue = strings.TrimSpace(line)
+	case 1:
+		strValue = flags.Arg(0)
+	default:
+		return fmt.Errorf("expected a single value to encrypt, got %d", flags.NArg())
+	}
+	value, ok := new(big.Int).SetString(strValue, 10)
+	if !ok || value.Sign() < 0 || value.Cmp(pk.Cache().NToS) >= 0 {
+		return fmt.Errorf("value must be a decimal number between 0 and n^s, got %q", strValue)
+	}
+	c, _, err := pk.Encrypt(value)
+	if err != nil {
+		return fmt.Errorf("error encrypting: %w", err)
+	}
+	bCipher, err := codec.MarshalCiphertext(c)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, bCipher)
+}
+
+// add adds homomorphically the ciphertext files provided and writes the
+// resulting ciphertext.
+func add(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	pubKeyFile := flags.String("pubkey", "", "public key file")
+	out := flags.String("out", "", "output ciphertext file (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("at least one ciphertext file is required")
+	}
+	if err := checkStdin(append([]string{*pubKeyFile}, flags.Args()...)...); err != nil {
+		return err
+	}
+	pk, err := readPubKey(*pubKeyFile, stdin)
+	if err != nil {
+		return err
+	}
+	ciphertexts := make([]*big.Int, flags.NArg())
+	for i, file := range flags.Args() {
+		data, err := readInput(file, stdin)
+		if err != nil {
+			return err
+		}
+		if ciphertexts[i], err = codec.UnmarshalCiphertext(pk, data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	sum, err := pk.Add(ciphertexts...)
+	if err != nil {
+		return fmt.Errorf("error adding ciphertexts: %w", err)
+	}
+	bCipher, err := codec.MarshalCiphertext(sum)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, bCipher)
+}
+
+// partialDecrypt computes the decryption share of the ciphertext file provided
+// with a key share and writes it.
+func partialDecrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("partial-decrypt", flag.ContinueOnError)
+	shareFile := flags.String("share", "", "key share file")
+	in := flags.String("in", "", "input ciphertext file (default stdin)")
+	out := flags.String("out", "", "output decryption share file (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *shareFile == "" {
+		return fmt.Errorf("key share file is required")
+	}
+	bShare, err := os.ReadFile(*shareFile)
+	if err != nil {
+		return err
+	}
+	share, err := codec.UnmarshalKeyShare(bShare)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *shareFile, err)
+	}
+	bCipher, err := readInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+	c, err := codec.UnmarshalCiphertext(share.PubKey, bCipher)
+	if err != nil {
+		return err
+	}
+	ds, err := share.PartialDecrypt(c)
+	if err != nil {
+		return fmt.Errorf("error decrypting: %w", err)
+	}
+	bDecShare, err := codec.MarshalDecryptionShare(ds)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, bDecShare)
+}
+
+// combine combines the decryption share files provided and writes the
+// plaintext as a decimal number.
+func combine(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("combine", flag.ContinueOnError)
+	pubKeyFile := flags.String("pubkey", "", "public key file")
+	out := flags.String("out", "", "output plaintext file (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := checkStdin(append([]string{*pubKeyFile}, flags.Args()...)...); err != nil {
+		return err
+	}
+	pk, err := readPubKey(*pubKeyFile, stdin)
+	if err != nil {
+		return err
+	}
+	shares := make([]*tcpaillier.DecryptionShare, flags.NArg())
+	for i, file := range flags.Args() {
+		data, err := readInput(file, stdin)
+		if err != nil {
+			return err
+		}
+		if shares[i], err = codec.UnmarshalDecryptionShare(pk, data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	plaintext, err := pk.CombineShares(shares...)
+	if err != nil {
+		return fmt.Errorf("error combining shares: %w", err)
+	}
+	return writeOutput(*out, stdout, []byte(plaintext.String()))
+}
+
+// readPubKey reads and decodes the public key file provided.
+func readPubKey(file string, stdin io.Reader) (*tcpaillier.PubKey, error) {
+	if file == "" {
+		return nil, fmt.Errorf("public key file is required")
+	}
+	data, err := readInput(file, stdin)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := codec.UnmarshalPubKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return pk, nil
+}
+
+// checkStdin checks that at most one of the input files provided is stdin,
+// since it can only be read once.
+func checkStdin(files ...string) error {
+	count := 0
+	for _, file := range files {
+		if file == "-" {
+			count++
+		}
+	}
+	if count > 1 {
+		return fmt.Errorf("stdin (-) can only be used for one input, got %d", count)
+	}
+	return nil
+}
+
+// readInput reads the file provided, or stdin if the path is empty or "-".
+func readInput(file string, stdin io.Reader) ([]byte, error) {
+	if file == "" || file == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// writeOutput writes the data provided followed by a new line to the file
+// provided, or to stdout if the path is empty or "-".
+func writeOutput(file string, stdout io.Writer, data []byte) error {
+	data = append(data, '\n')
+	if file == "" || file == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
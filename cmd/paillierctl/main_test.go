@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCeremony(t *testing.T) {
+	dir := t.TempDir()
+	pubKey := filepath.Join(dir, "pubkey.json")
+	// generate the key
+	if err := run([]string{"keygen", "-bits", "128", "-l", "5", "-k", "3", "-out", dir}, nil, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	// encrypt values from arguments and stdin
+	ciphertexts := []string{}
+	for i, value := range []string{"10", "20", "12"} {
+		file := filepath.Join(dir, fmt.Sprintf("c%d.json", i))
+		args := []string{"encrypt", "-pubkey", pubKey, "-out", file}
+		stdin := strings.NewReader(value + "\n")
+		if i%2 == 0 {
+			args, stdin = append(args, value), nil
+		}
+		if err := run(args, stdin, &bytes.Buffer{}); err != nil {
+			t.Fatalf("Error encrypting %s: %v\n", value, err)
+		}
+		ciphertexts = append(ciphertexts, file)
+	}
+	// add the ciphertexts writing the result to stdout
+	sum := &bytes.Buffer{}
+	if err := run(append([]string{"add", "-pubkey", pubKey}, ciphertexts...), nil, sum); err != nil {
+		t.Fatalf("Error adding: %v\n", err)
+	}
+	// partial decrypt with 3 shares reading the sum from stdin
+	decShares := []string{}
+	for _, index := range []int{1, 3, 5} {
+		file := filepath.Join(dir, fmt.Sprintf("ds%d.json", index))
+		share := filepath.Join(dir, fmt.Sprintf("share-%d.json", index))
+		args := []string{"partial-decrypt", "-share", share, "-out", file}
+		if err := run(args, bytes.NewReader(sum.Bytes()), &bytes.Buffer{}); err != nil {
+			t.Fatalf("Error decrypting with share %d: %v\n", index, err)
+		}
+		decShares = append(decShares, file)
+	}
+	// combine the decryption shares
+	plaintext := &bytes.Buffer{}
+	if err := run(append([]string{"combine", "-pubkey", pubKey}, decShares...), nil, plaintext); err != nil {
+		t.Fatalf("Error combining: %v\n", err)
+	}
+	if result := strings.TrimSpace(plaintext.String()); result != "42" {
+		t.Errorf("Unexpected plaintext: expected 42, got %s\n", result)
+	}
+	// not enough shares
+	if err := run(append([]string{"combine", "-pubkey", pubKey}, decShares[:2]...), nil, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error combining less than k shares")
+	}
+	// stdin can only be read once
+	for _, args := range [][]string{
+		{"add", "-pubkey", pubKey, "-", "-"},
+		{"add", "-pubkey", "-", ciphertexts[0], "-"},
+		{"combine", "-pubkey", pubKey, "-", decShares[0], "-"},
+		{"encrypt", "-pubkey", "-"},
+	} {
+		err := run(args, bytes.NewReader(sum.Bytes()), &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "stdin") {
+			t.Errorf("Expected stdin error running %v, got %v\n", args, err)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+// The binary encodings start with the version and a type tag byte, followed
+// by the fields of the value. Big integers are encoded as an uvarint length
+// followed by its big-endian bytes without leading zeros, and the list of
+// verification keys is prefixed by its uvarint length.
+
+// EncodePubKey returns the binary encoding of the public key provided.
+func EncodePubKey(pk *tcpaillier.PubKey) ([]byte, error) {
+	w := newWriter(tagPubKey)
+	if err := w.pubKey(pk); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// DecodePubKey decodes and validates a binary encoded public key.
+func DecodePubKey(data []byte) (*tcpaillier.PubKey, error) {
+	r, err := newReader(data, tagPubKey)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := r.pubKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// EncodeKeyShare returns the binary encoding of the key share provided, it
+// includes the public key of the share.
+func EncodeKeyShare(share *tcpaillier.KeyShare) ([]byte, error) {
+	if share == nil || share.Si == nil {
+		return nil, fmt.Errorf("nil key share")
+	}
+	w := newWriter(tagKeyShare)
+	if err := w.pubKey(share.PubKey); err != nil {
+		return nil, err
+	}
+	w.byte(share.Index)
+	w.bigInt(share.Si)
+	return w.buf, nil
+}
+
+// DecodeKeyShare decodes and validates a binary encoded key share.
+func DecodeKeyShare(data []byte) (*tcpaillier.KeyShare, error) {
+	r, err := newReader(data, tagKeyShare)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := r.pubKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	index, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	si, err := r.bigInt()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return newKeyShare(pk, index, si)
+}
+
+// EncodeCiphertext returns the binary encoding of the ciphertext provided.
+func EncodeCiphertext(c *big.Int) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("nil ciphertext")
+	}
+	w := newWriter(tagCiphertext)
+	w.bigInt(c)
+	return w.buf, nil
+}
+
+// DecodeCiphertext decodes a binary encoded ciphertext and validates it
+// against the public key provided.
+func DecodeCiphertext(pk *tcpaillier.PubKey, data []byte) (*big.Int, error) {
+	r, err := newReader(data, tagCiphertext)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.bigInt()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if err := checkCiphertext(pk, c); err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	return c, nil
+}
+
+// EncodeDecryptionShare returns the binary encoding of the decryption share
+// provided.
+func EncodeDecryptionShare(ds *tcpaillier.DecryptionShare) ([]byte, error) {
+	if ds == nil || ds.Ci == nil {
+		return nil, fmt.Errorf("nil decryption share")
+	}
+	w := newWriter(tagDecryptionShare)
+	w.byte(ds.Index)
+	w.bigInt(ds.Ci)
+	return w.buf, nil
+}
+
+// DecodeDecryptionShare decodes a binary encoded decryption share and
+// validates it against the public key provided.
+func DecodeDecryptionShare(pk *tcpaillier.PubKey, data []byte) (*tcpaillier.DecryptionShare, error) {
+	r, err := newReader(data, tagDecryptionShare)
+	if err != nil {
+		return nil, err
+	}
+	index, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	ci, err := r.bigInt()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return newDecryptionShare(pk, index, ci)
+}
+
+// writer appends the binary encoding of values to a buffer.
+type writer struct {
+	buf []byte
+}
+
+func newWriter(tag byte) *writer {
+	return &writer{buf: []byte{Version, tag}}
+}
+
+func (w *writer) byte(b byte) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *writer) uvarint(x uint64) {
+	w.buf = binary.AppendUvarint(w.buf, x)
+}
+
+func (w *writer) bigInt(x *big.Int) {
+	b := x.Bytes()
+	w.uvarint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) pubKey(pk *tcpaillier.PubKey) error {
+	if pk == nil || pk.N == nil || pk.V == nil {
+		return fmt.Errorf("nil public key")
+	}
+	w.bigInt(pk.N)
+	w.byte(pk.S)
+	w.byte(pk.L)
+	w.byte(pk.K)
+	w.bigInt(pk.V)
+	w.uvarint(uint64(len(pk.Vi)))
+	for i, v := range pk.Vi {
+		if v == nil {
+			return fmt.Errorf("nil verification key %d", i+1)
+		}
+		w.bigInt(v)
+	}
+	return nil
+}
+
+// reader decodes values from a binary encoding.
+type reader struct {
+	buf []byte
+}
+
+// newReader checks the version and the type tag of the data provided and
+// returns a reader of the rest of it.
+func newReader(data []byte, tag byte) (*reader, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("data too short")
+	}
+	if err := checkVersion(int(data[0])); err != nil {
+		return nil, err
+	}
+	if data[1] != tag {
+		return nil, fmt.Errorf("unexpected type tag %d, expected %d", data[1], tag)
+	}
+	return &reader{buf: data[2:]}, nil
+}
+
+func (r *reader) byte() (byte, error) {
+	if len(r.buf) < 1 {
+		return 0, fmt.Errorf("unexpected end of data")
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b, nil
+}
+
+func (r *reader) uvarint() (uint64, error) {
+	x, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid length")
+	}
+	r.buf = r.buf[n:]
+	return x, nil
+}
+
+func (r *reader) bigInt() (*big.Int, error) {
+	size, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(len(r.buf)) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	b := r.buf[:size]
+	if size > 0 && b[0] == 0 {
+		return nil, fmt.Errorf("non canonical big integer with leading zeros")
+	}
+	r.buf = r.buf[size:]
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (r *reader) pubKey() (*tcpaillier.PubKey, error) {
+	n, err := r.bigInt()
+	if err != nil {
+		return nil, err
+	}
+	var params [3]byte // s, l, k
+	for i := range params {
+		if params[i], err = r.byte(); err != nil {
+			return nil, err
+		}
+	}
+	v, err := r.bigInt()
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	// every verification key takes at least one byte
+	if count > uint64(len(r.buf)) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	vi := make([]*big.Int, count)
+	for i := range vi {
+		if vi[i], err = r.bigInt(); err != nil {
+			return nil, err
+		}
+	}
+	return newPubKey(n, params[0], params[1], params[2], v, vi)
+}
+
+// done checks that all the data has been read.
+func (r *reader) done() error {
+	if len(r.buf) != 0 {
+		return fmt.Errorf("unexpected %d bytes after the encoded value", len(r.buf))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package codec implements the canonical JSON and binary encodings of the
+// threshold Paillier public keys, key shares, ciphertexts and decryption
+// shares of github.com/niclabs/tcpaillier, so keys can be generated once and
+// distributed to trustees and clients. Every encoding is versioned and it is
+// strictly validated on decode.
+package codec
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+// Version is the current version of the encodings.
+const Version = 1
+
+// binary type tags, the second byte of every binary encoding
+const (
+	tagPubKey          = byte(1)
+	tagKeyShare        = byte(2)
+	tagCiphertext      = byte(3)
+	tagDecryptionShare = byte(4)
+)
+
+var one = big.NewInt(1)
+
+// newPubKey returns a tcpaillier public key from its public components,
+// checking that they are valid and computing its derived values, delta and
+// the decryption constant.
+func newPubKey(n *big.Int, s, l, k uint8, v *big.Int, vi []*big.Int) (*tcpaillier.PubKey, error) {
+	if n.Cmp(big.NewInt(2)) <= 0 || n.Bit(0) == 0 {
+		return nil, fmt.Errorf("n must be an odd number greater than 2")
+	}
+	if s < 1 {
+		return nil, fmt.Errorf("s should be at least 1, but it is %d", s)
+	}
+	if l <= 1 {
+		return nil, fmt.Errorf("l should be greater than 1, but it is %d", l)
+	}
+	if k < (l/2+1) || k > l {
+		return nil, fmt.Errorf("k should be between %d and %d, but it is %d", l/2+1, l, k)
+	}
+	if len(vi) != int(l) {
+		return nil, fmt.Errorf("expected %d verification keys, got %d", l, len(vi))
+	}
+	pk := &tcpaillier.PubKey{
+		N:  n,
+		S:  s,
+		L:  l,
+		K:  k,
+		V:  v,
+		Vi: vi,
+	}
+	cache := pk.Cache()
+	if err := checkRange(v, cache.NToSPlusOne); err != nil {
+		return nil, fmt.Errorf("invalid v: %w", err)
+	}
+	for i, vk := range vi {
+		if err := checkRange(vk, cache.NToSPlusOne); err != nil {
+			return nil, fmt.Errorf("invalid verification key %d: %w", i+1, err)
+		}
+	}
+	// delta = l!
+	pk.Delta = new(big.Int).MulRange(1, int64(l))
+	// constant = (4 * delta^2)^-1 mod n^s
+	pk.Constant = new(big.Int).Mul(pk.Delta, pk.Delta)
+	pk.Constant.Mul(pk.Constant, big.NewInt(4))
+	if pk.Constant.ModInverse(pk.Constant, cache.NToS) == nil {
+		return nil, fmt.Errorf("4 * delta^2 has no inverse mod n^s")
+	}
+	return pk, nil
+}
+
+// newKeyShare returns a tcpaillier key share of the public key provided,
+// checking that the index is in range and that the share matches its
+// verification key, Vi = V^(delta*Si) mod n^(s+1).
+func newKeyShare(pk *tcpaillier.PubKey, index uint8, si *big.Int) (*tcpaillier.KeyShare, error) {
+	if index < 1 || index > pk.L {
+		return nil, fmt.Errorf("index must be between 1 and %d, got %d", pk.L, index)
+	}
+	if si.Sign() <= 0 {
+		return nil, fmt.Errorf("share must be positive")
+	}
+	deltaSi := new(big.Int).Mul(si, pk.Delta)
+	if new(big.Int).Exp(pk.V, deltaSi, pk.Cache().NToSPlusOne).Cmp(pk.Vi[index-1]) != 0 {
+		return nil, fmt.Errorf("share %d does not match its verification key", index)
+	}
+	return &tcpaillier.KeyShare{
+		PubKey: pk,
+		Index:  index,
+		Si:     si,
+	}, nil
+}
+
+// checkCiphertext checks that the ciphertext is valid for the public key
+// provided.
+func checkCiphertext(pk *tcpaillier.PubKey, c *big.Int) error {
+	if pk == nil || pk.N == nil {
+		return fmt.Errorf("public key is required")
+	}
+	return checkRange(c, pk.Cache().NToSPlusOne)
+}
+
+// newDecryptionShare returns a tcpaillier decryption share checking that it
+// is valid for the public key provided.
+func newDecryptionShare(pk *tcpaillier.PubKey, index uint8, ci *big.Int) (*tcpaillier.DecryptionShare, error) {
+	if pk == nil || pk.N == nil {
+		return nil, fmt.Errorf("public key is required")
+	}
+	if index < 1 || index > pk.L {
+		return nil, fmt.Errorf("index must be between 1 and %d, got %d", pk.L, index)
+	}
+	if err := checkRange(ci, pk.Cache().NToSPlusOne); err != nil {
+		return nil, fmt.Errorf("invalid decryption share: %w", err)
+	}
+	return &tcpaillier.DecryptionShare{
+		Index: index,
+		Ci:    ci,
+	}, nil
+}
+
+// checkRange checks that x is in [1, mod).
+func checkRange(x, mod *big.Int) error {
+	if x.Cmp(one) < 0 || x.Cmp(mod) >= 0 {
+		return fmt.Errorf("must be between 1 (inclusive) and n^(s+1) (exclusive)")
+	}
+	return nil
+}
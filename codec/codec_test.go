@@ -0,0 +1,176 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+func TestRoundTrip(t *testing.T) {
+	shares, pk, err := tcpaillier.NewKey(128, 1, 5, 3)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	msg := big.NewInt(123456789)
+	c, _, err := pk.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	for _, format := range []struct {
+		name           string
+		encodePubKey   func(*tcpaillier.PubKey) ([]byte, error)
+		decodePubKey   func([]byte) (*tcpaillier.PubKey, error)
+		encodeShare    func(*tcpaillier.KeyShare) ([]byte, error)
+		decodeShare    func([]byte) (*tcpaillier.KeyShare, error)
+		encodeCipher   func(*big.Int) ([]byte, error)
+		decodeCipher   func(*tcpaillier.PubKey, []byte) (*big.Int, error)
+		encodeDecShare func(*tcpaillier.DecryptionShare) ([]byte, error)
+		decodeDecShare func(*tcpaillier.PubKey, []byte) (*tcpaillier.DecryptionShare, error)
+	}{
+		{"json", MarshalPubKey, UnmarshalPubKey, MarshalKeyShare, UnmarshalKeyShare,
+			MarshalCiphertext, UnmarshalCiphertext, MarshalDecryptionShare, UnmarshalDecryptionShare},
+		{"binary", EncodePubKey, DecodePubKey, EncodeKeyShare, DecodeKeyShare,
+			EncodeCiphertext, DecodeCiphertext, EncodeDecryptionShare, DecodeDecryptionShare},
+	} {
+		// public key
+		bPubKey, err := format.encodePubKey(pk)
+		if err != nil {
+			t.Fatalf("[%s] Error encoding public key: %v\n", format.name, err)
+		}
+		decPubKey, err := format.decodePubKey(bPubKey)
+		if err != nil {
+			t.Fatalf("[%s] Error decoding public key: %v\n", format.name, err)
+		}
+		if decPubKey.N.Cmp(pk.N) != 0 || decPubKey.Delta.Cmp(pk.Delta) != 0 ||
+			decPubKey.Constant.Cmp(pk.Constant) != 0 {
+			t.Errorf("[%s] Decoded public key does not match\n", format.name)
+		}
+		// ciphertext
+		bCipher, err := format.encodeCipher(c)
+		if err != nil {
+			t.Fatalf("[%s] Error encoding ciphertext: %v\n", format.name, err)
+		}
+		decC, err := format.decodeCipher(decPubKey, bCipher)
+		if err != nil {
+			t.Fatalf("[%s] Error decoding ciphertext: %v\n", format.name, err)
+		}
+		// decrypt with decoded key shares and decryption shares
+		decryptionShares := []*tcpaillier.DecryptionShare{}
+		for _, share := range shares[:pk.K] {
+			bShare, err := format.encodeShare(share)
+			if err != nil {
+				t.Fatalf("[%s] Error encoding key share: %v\n", format.name, err)
+			}
+			decShare, err := format.decodeShare(bShare)
+			if err != nil {
+				t.Fatalf("[%s] Error decoding key share: %v\n", format.name, err)
+			}
+			ds, err := decShare.PartialDecrypt(decC)
+			if err != nil {
+				t.Fatalf("[%s] Error decrypting: %v\n", format.name, err)
+			}
+			bDecShare, err := format.encodeDecShare(ds)
+			if err != nil {
+				t.Fatalf("[%s] Error encoding decryption share: %v\n", format.name, err)
+			}
+			decDecShare, err := format.decodeDecShare(decPubKey, bDecShare)
+			if err != nil {
+				t.Fatalf("[%s] Error decoding decryption share: %v\n", format.name, err)
+			}
+			decryptionShares = append(decryptionShares, decDecShare)
+		}
+		dec, err := decPubKey.CombineShares(decryptionShares...)
+		if err != nil {
+			t.Fatalf("[%s] Error combining shares: %v\n", format.name, err)
+		}
+		if dec.Cmp(msg) != 0 {
+			t.Errorf("[%s] Unexpected plaintext: expected %s, got %s\n", format.name, msg, dec)
+		}
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	shares, pk, err := tcpaillier.NewKey(64, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	bPubKey, err := MarshalPubKey(pk)
+	if err != nil {
+		t.Fatalf("Error encoding public key: %v\n", err)
+	}
+	mutate := func(f func(*PubKeyJSON)) []byte {
+		pkJSON := &PubKeyJSON{}
+		if err := json.Unmarshal(bPubKey, pkJSON); err != nil {
+			t.Fatalf("Error decoding public key: %v\n", err)
+		}
+		f(pkJSON)
+		b, _ := json.Marshal(pkJSON)
+		return b
+	}
+	for name, data := range map[string][]byte{
+		"unknown field":     bytes.Replace(bPubKey, []byte(`"version"`), []byte(`"foo":1,"version"`), 1),
+		"trailing data":     append(append([]byte{}, bPubKey...), []byte(`{}`)...),
+		"bad version":       mutate(func(p *PubKeyJSON) { p.Version = Version + 1 }),
+		"leading zeros":     mutate(func(p *PubKeyJSON) { p.N = "0" + p.N }),
+		"missing vi":        mutate(func(p *PubKeyJSON) { p.Vi = p.Vi[1:] }),
+		"bad threshold":     mutate(func(p *PubKeyJSON) { p.K = 1 }),
+		"v out of range":    mutate(func(p *PubKeyJSON) { p.V = "0" }),
+		"negative n":        mutate(func(p *PubKeyJSON) { p.N = "-" + p.N }),
+		"non decimal value": mutate(func(p *PubKeyJSON) { p.V = "0x10" }),
+	} {
+		if _, err := UnmarshalPubKey(data); err == nil {
+			t.Errorf("Expected error decoding public key with %s\n", name)
+		}
+	}
+	// a share that does not match its verification key
+	tampered := *shares[0]
+	tampered.Si = new(big.Int).Add(shares[0].Si, big.NewInt(1))
+	bShare, err := MarshalKeyShare(&tampered)
+	if err != nil {
+		t.Fatalf("Error encoding key share: %v\n", err)
+	}
+	if _, err := UnmarshalKeyShare(bShare); err == nil {
+		t.Error("Expected error decoding a tampered key share")
+	}
+	// a ciphertext out of range
+	bCipher, _ := MarshalCiphertext(pk.Cache().NToSPlusOne)
+	if _, err := UnmarshalCiphertext(pk, bCipher); err == nil {
+		t.Error("Expected error decoding a ciphertext out of range")
+	}
+	// a decryption share with an index out of range
+	bDecShare, _ := MarshalDecryptionShare(&tcpaillier.DecryptionShare{Index: pk.L + 1, Ci: big.NewInt(2)})
+	if _, err := UnmarshalDecryptionShare(pk, bDecShare); err == nil {
+		t.Error("Expected error decoding a decryption share out of range")
+	}
+}
+
+func TestStrictBinary(t *testing.T) {
+	_, pk, err := tcpaillier.NewKey(64, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	bPubKey, err := EncodePubKey(pk)
+	if err != nil {
+		t.Fatalf("Error encoding public key: %v\n", err)
+	}
+	bCipher, _ := EncodeCiphertext(big.NewInt(2))
+	for name, data := range map[string][]byte{
+		"empty":         {},
+		"bad version":   append([]byte{Version + 1}, bPubKey[1:]...),
+		"bad tag":       append([]byte{Version, tagCiphertext}, bPubKey[2:]...),
+		"trailing data": append(append([]byte{}, bPubKey...), 0),
+		"truncated":     bPubKey[:len(bPubKey)-1],
+		"ciphertext":    bCipher,
+	} {
+		if _, err := DecodePubKey(data); err == nil {
+			t.Errorf("Expected error decoding public key with %s\n", name)
+		}
+	}
+	// leading zeros in a big integer
+	if _, err := DecodeCiphertext(pk, []byte{Version, tagCiphertext, 2, 0, 2}); err == nil {
+		t.Error("Expected error decoding a non canonical ciphertext")
+	}
+}
@@ -0,0 +1,238 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+// PubKeyJSON is the JSON representation of a public key. Big integers are
+// encoded as decimal strings.
+type PubKeyJSON struct {
+	Version int      `json:"version"`
+	N       string   `json:"n"`
+	S       uint8    `json:"s"`
+	L       uint8    `json:"l"`
+	K       uint8    `json:"k"`
+	V       string   `json:"v"`
+	Vi      []string `json:"vi"`
+}
+
+// KeyShareJSON is the JSON representation of a key share, it includes the
+// public key of the share.
+type KeyShareJSON struct {
+	Version int         `json:"version"`
+	PubKey  *PubKeyJSON `json:"pubkey"`
+	Index   uint8       `json:"index"`
+	Si      string      `json:"si"`
+}
+
+// CiphertextJSON is the JSON representation of a ciphertext.
+type CiphertextJSON struct {
+	Version    int    `json:"version"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// DecryptionShareJSON is the JSON representation of a decryption share.
+type DecryptionShareJSON struct {
+	Version int    `json:"version"`
+	Index   uint8  `json:"index"`
+	Ci      string `json:"ci"`
+}
+
+// MarshalPubKey returns the JSON encoding of the public key provided.
+func MarshalPubKey(pk *tcpaillier.PubKey) ([]byte, error) {
+	pkJSON, err := pubKeyToJSON(pk)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(pkJSON)
+}
+
+// UnmarshalPubKey decodes and validates a JSON encoded public key.
+func UnmarshalPubKey(data []byte) (*tcpaillier.PubKey, error) {
+	pkJSON := &PubKeyJSON{}
+	if err := strictUnmarshal(data, pkJSON); err != nil {
+		return nil, err
+	}
+	return pubKeyFromJSON(pkJSON)
+}
+
+// MarshalKeyShare returns the JSON encoding of the key share provided.
+func MarshalKeyShare(share *tcpaillier.KeyShare) ([]byte, error) {
+	if share == nil || share.Si == nil {
+		return nil, fmt.Errorf("nil key share")
+	}
+	pkJSON, err := pubKeyToJSON(share.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&KeyShareJSON{
+		Version: Version,
+		PubKey:  pkJSON,
+		Index:   share.Index,
+		Si:      share.Si.String(),
+	})
+}
+
+// UnmarshalKeyShare decodes and validates a JSON encoded key share.
+func UnmarshalKeyShare(data []byte) (*tcpaillier.KeyShare, error) {
+	shareJSON := &KeyShareJSON{}
+	if err := strictUnmarshal(data, shareJSON); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(shareJSON.Version); err != nil {
+		return nil, err
+	}
+	if shareJSON.PubKey == nil {
+		return nil, fmt.Errorf("missing public key")
+	}
+	pk, err := pubKeyFromJSON(shareJSON.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	si, err := parseBigInt("si", shareJSON.Si)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyShare(pk, shareJSON.Index, si)
+}
+
+// MarshalCiphertext returns the JSON encoding of the ciphertext provided.
+func MarshalCiphertext(c *big.Int) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("nil ciphertext")
+	}
+	return json.Marshal(&CiphertextJSON{
+		Version:    Version,
+		Ciphertext: c.String(),
+	})
+}
+
+// UnmarshalCiphertext decodes a JSON encoded ciphertext and validates it
+// against the public key provided.
+func UnmarshalCiphertext(pk *tcpaillier.PubKey, data []byte) (*big.Int, error) {
+	cJSON := &CiphertextJSON{}
+	if err := strictUnmarshal(data, cJSON); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(cJSON.Version); err != nil {
+		return nil, err
+	}
+	c, err := parseBigInt("ciphertext", cJSON.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCiphertext(pk, c); err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	return c, nil
+}
+
+// MarshalDecryptionShare returns the JSON encoding of the decryption share
+// provided.
+func MarshalDecryptionShare(ds *tcpaillier.DecryptionShare) ([]byte, error) {
+	if ds == nil || ds.Ci == nil {
+		return nil, fmt.Errorf("nil decryption share")
+	}
+	return json.Marshal(&DecryptionShareJSON{
+		Version: Version,
+		Index:   ds.Index,
+		Ci:      ds.Ci.String(),
+	})
+}
+
+// UnmarshalDecryptionShare decodes a JSON encoded decryption share and
+// validates it against the public key provided.
+func UnmarshalDecryptionShare(pk *tcpaillier.PubKey, data []byte) (*tcpaillier.DecryptionShare, error) {
+	dsJSON := &DecryptionShareJSON{}
+	if err := strictUnmarshal(data, dsJSON); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(dsJSON.Version); err != nil {
+		return nil, err
+	}
+	ci, err := parseBigInt("ci", dsJSON.Ci)
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptionShare(pk, dsJSON.Index, ci)
+}
+
+func pubKeyToJSON(pk *tcpaillier.PubKey) (*PubKeyJSON, error) {
+	if pk == nil || pk.N == nil || pk.V == nil {
+		return nil, fmt.Errorf("nil public key")
+	}
+	vi := make([]string, len(pk.Vi))
+	for i, v := range pk.Vi {
+		if v == nil {
+			return nil, fmt.Errorf("nil verification key %d", i+1)
+		}
+		vi[i] = v.String()
+	}
+	return &PubKeyJSON{
+		Version: Version,
+		N:       pk.N.String(),
+		S:       pk.S,
+		L:       pk.L,
+		K:       pk.K,
+		V:       pk.V.String(),
+		Vi:      vi,
+	}, nil
+}
+
+func pubKeyFromJSON(pkJSON *PubKeyJSON) (*tcpaillier.PubKey, error) {
+	if err := checkVersion(pkJSON.Version); err != nil {
+		return nil, err
+	}
+	n, err := parseBigInt("n", pkJSON.N)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseBigInt("v", pkJSON.V)
+	if err != nil {
+		return nil, err
+	}
+	vi := make([]*big.Int, len(pkJSON.Vi))
+	for i, str := range pkJSON.Vi {
+		if vi[i], err = parseBigInt(fmt.Sprintf("vi[%d]", i), str); err != nil {
+			return nil, err
+		}
+	}
+	return newPubKey(n, pkJSON.S, pkJSON.L, pkJSON.K, v, vi)
+}
+
+// strictUnmarshal decodes the JSON data provided into v, failing on unknown
+// fields and trailing data.
+func strictUnmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// parseBigInt parses a canonical decimal representation of a non-negative
+// big integer, without sign or leading zeros.
+func parseBigInt(name, str string) (*big.Int, error) {
+	x, ok := new(big.Int).SetString(str, 10)
+	if !ok || x.Sign() < 0 || x.String() != str {
+		return nil, fmt.Errorf("invalid %s: %q is not a canonical decimal number", name, str)
+	}
+	return x, nil
+}
+
+func checkVersion(version int) error {
+	if version != Version {
+		return fmt.Errorf("unsupported version %d, expected %d", version, Version)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package dkg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// EncryptedShare is a share encrypted to its recipient with hashed ElGamal in
+// the DKG group: the dealer picks a random r and publishes g^r and the share
+// xored with a key stream derived from y^r, where y is the encryption key of
+// the recipient. Big integers are decimal strings and the ciphertext is hex.
+type EncryptedShare struct {
+	Recipient  int    `json:"recipient"`
+	Ephemeral  string `json:"ephemeral"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// DealMessage bundles the commitments of a dealer and its shares encrypted to
+// every other participant, so the deal can be published on a public
+// append-only log instead of being sent through private channels.
+type DealMessage struct {
+	Dealer          int              `json:"dealer"`
+	Commitments     []string         `json:"commitments"`
+	EncryptedShares []EncryptedShare `json:"encrypted_shares"`
+}
+
+// Marshal returns the JSON encoding of the message.
+func (m *DealMessage) Marshal() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// UnmarshalDealMessage decodes a JSON encoded deal message.
+func UnmarshalDealMessage(data []byte) (*DealMessage, error) {
+	m := &DealMessage{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("error decoding deal message: %w", err)
+	}
+	return m, nil
+}
+
+// EncryptionKey returns the public key that the dealers must use to encrypt
+// the shares of the participant, g^sk.
+func (pt *Participant) EncryptionKey() *big.Int {
+	return pt.params.Group.Exp(pt.params.Group.Generator(), pt.encSecret)
+}
+
+// DealMessage deals like Deal, and returns the deal as a broadcast message
+// with the share of every other participant encrypted to it.
+// encryptionKeys[j-1] is the encryption key of the participant j.
+func (pt *Participant) DealMessage(encryptionKeys []*big.Int) (*DealMessage, error) {
+	if len(encryptionKeys) != pt.params.Participants {
+		return nil, fmt.Errorf("expected %d encryption keys, got %d", pt.params.Participants, len(encryptionKeys))
+	}
+	for i, key := range encryptionKeys {
+		if err := checkGroupElement(pt.params, key); err != nil {
+			return nil, fmt.Errorf("invalid encryption key of participant %d: %w", i+1, err)
+		}
+	}
+	deal, err := pt.Deal()
+	if err != nil {
+		return nil, err
+	}
+	msg := &DealMessage{
+		Dealer:      deal.Dealer,
+		Commitments: make([]string, len(deal.Commitments)),
+	}
+	for i, c := range deal.Commitments {
+		msg.Commitments[i] = c.String()
+	}
+	for j := 1; j <= pt.params.Participants; j++ {
+		if j == pt.index {
+			continue
+		}
+		encShare, err := EncryptShare(pt.random, pt.params, encryptionKeys[j-1], deal.Dealer, j, deal.Shares[j])
+		if err != nil {
+			return nil, err
+		}
+		msg.EncryptedShares = append(msg.EncryptedShares, *encShare)
+	}
+	return msg, nil
+}
+
+// ReceiveMessage decodes the commitments of the deal message provided,
+// decrypts the share of the participant and receives them like Receive. If
+// the share is missing or can not be decrypted, the commitments are received
+// anyway and an error is returned, the participant will complain against the
+// dealer in Verify.
+func (pt *Participant) ReceiveMessage(msg *DealMessage) error {
+	if msg == nil {
+		return fmt.Errorf("nil deal message")
+	}
+	commitments := make([]*Commitment, len(msg.Commitments))
+	for i, c := range msg.Commitments {
+		value, ok := new(big.Int).SetString(c, 10)
+		if !ok {
+			return fmt.Errorf("invalid commitment %d of dealer %d", i, msg.Dealer)
+		}
+		var err error
+		if commitments[i], err = NewCommitment(pt.params.Group, value); err != nil {
+			return fmt.Errorf("invalid commitment %d of dealer %d: %w", i, msg.Dealer, err)
+		}
+	}
+	for _, encShare := range msg.EncryptedShares {
+		if encShare.Recipient != pt.index {
+			continue
+		}
+		share, decErr := DecryptShare(pt.params, pt.encSecret, msg.Dealer, encShare)
+		// the commitments are kept even if the share can not be decrypted,
+		// to complain against the dealer and check its justification
+		if err := pt.receive(msg.Dealer, commitments, share); err != nil {
+			return err
+		}
+		if decErr != nil {
+			return fmt.Errorf("error decrypting share of dealer %d: %w", msg.Dealer, decErr)
+		}
+		return nil
+	}
+	if err := pt.receive(msg.Dealer, commitments, nil); err != nil {
+		return err
+	}
+	return fmt.Errorf("no share for participant %d in the deal of dealer %d", pt.index, msg.Dealer)
+}
+
+// EncryptShare encrypts the share from the dealer to the recipient provided
+// with the encryption key of the recipient, with an ephemeral key read from
+// the randomness source provided.
+func EncryptShare(random io.Reader, params Params, encryptionKey *big.Int, dealer, recipient int,
+	share *big.Int,
+) (*EncryptedShare, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkIndices(params, dealer, recipient); err != nil {
+		return nil, err
+	}
+	group := params.Group
+	if share == nil || share.Sign() < 0 || share.Cmp(group.Order()) >= 0 {
+		return nil, fmt.Errorf("share must be between 0 and q")
+	}
+	if err := checkGroupElement(params, encryptionKey); err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	// r must be in [1, q) for g^r to be different from the identity
+	r, err := RandomScalar(random, group)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral := group.Exp(group.Generator(), r)
+	sharedKey := group.Exp(encryptionKey, r)
+	plaintext := share.FillBytes(make([]byte, scalarSize(group)))
+	ciphertext := xorKeyStream(sharedKey, dealer, recipient, plaintext)
+	return &EncryptedShare{
+		Recipient:  recipient,
+		Ephemeral:  ephemeral.String(),
+		Ciphertext: hex.EncodeToString(ciphertext),
+	}, nil
+}
+
+// DecryptShare decrypts the share encrypted by the dealer provided with the
+// secret encryption key of the recipient.
+func DecryptShare(params Params, secret *big.Int, dealer int, encShare EncryptedShare) (*big.Int, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkIndices(params, dealer, encShare.Recipient); err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Sign() <= 0 || secret.Cmp(params.Group.Order()) >= 0 {
+		return nil, fmt.Errorf("secret key must be between 1 and q")
+	}
+	ephemeral, ok := new(big.Int).SetString(encShare.Ephemeral, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid ephemeral key")
+	}
+	if err := checkGroupElement(params, ephemeral); err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(encShare.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	if len(ciphertext) != scalarSize(params.Group) {
+		return nil, fmt.Errorf("invalid ciphertext length %d", len(ciphertext))
+	}
+	sharedKey := params.Group.Exp(ephemeral, secret)
+	share := new(big.Int).SetBytes(xorKeyStream(sharedKey, dealer, encShare.Recipient, ciphertext))
+	if share.Cmp(params.Group.Order()) >= 0 {
+		return nil, fmt.Errorf("decrypted share out of range")
+	}
+	return share, nil
+}
+
+// scalarSize returns the number of bytes of a scalar of the group.
+func scalarSize(group Group) int {
+	return (group.Order().BitLen() + 7) / 8
+}
+
+// xorKeyStream xors the data provided with a key stream derived from the
+// shared key and the dealer and recipient indexes, the blocks of the stream
+// are SHA-256(sharedKey || dealer || recipient || counter). The shared key is
+// a group element, and its encoding is followed by fixed size values.
+func xorKeyStream(sharedKey *big.Int, dealer, recipient int, data []byte) []byte {
+	seed := sharedKey.Bytes()
+	seed = binary.BigEndian.AppendUint32(seed, uint32(dealer))
+	seed = binary.BigEndian.AppendUint32(seed, uint32(recipient))
+	out := make([]byte, len(data))
+	for offset, counter := 0, uint32(0); offset < len(data); offset, counter = offset+sha256.Size, counter+1 {
+		block := sha256.Sum256(binary.BigEndian.AppendUint32(append([]byte{}, seed...), counter))
+		for i := 0; i < sha256.Size && offset+i < len(data); i++ {
+			out[offset+i] = data[offset+i] ^ block[i]
+		}
+	}
+	return out
+}
+
+// checkIndices checks that the dealer and the recipient of a share are
+// distinct participants.
+func checkIndices(params Params, dealer, recipient int) error {
+	if dealer < 1 || dealer > params.Participants {
+		return fmt.Errorf("dealer must be between 1 and %d, got %d", params.Participants, dealer)
+	}
+	if recipient < 1 || recipient > params.Participants {
+		return fmt.Errorf("recipient must be between 1 and %d, got %d", params.Participants, recipient)
+	}
+	if dealer == recipient {
+		return fmt.Errorf("dealer and recipient must be different")
+	}
+	return nil
+}
+
+// checkGroupElement checks that x is an element of the group different from
+// the identity.
+func checkGroupElement(params Params, x *big.Int) error {
+	if !params.Group.IsElement(x) {
+		return fmt.Errorf("value not in the group")
+	}
+	if x.Cmp(params.Group.Identity()) == 0 {
+		return fmt.Errorf("value is the identity")
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestDealMessage(t *testing.T) {
+	params := testParams(BabyJubJub, 5, 3)
+	participants := newParticipants(t, params)
+	encryptionKeys := make([]*big.Int, len(participants))
+	for i, pt := range participants {
+		encryptionKeys[i] = pt.EncryptionKey()
+	}
+	// every participant publishes its deal to the log
+	log := [][]byte{}
+	for _, pt := range participants {
+		msg, err := pt.DealMessage(encryptionKeys)
+		if err != nil {
+			t.Fatalf("Error dealing: %v\n", err)
+		}
+		// the dealer 3 corrupts the share of the participant 1
+		if msg.Dealer == 3 {
+			ciphertext := msg.EncryptedShares[0].Ciphertext
+			if ciphertext[:2] == "00" {
+				msg.EncryptedShares[0].Ciphertext = "ff" + ciphertext[2:]
+			} else {
+				msg.EncryptedShares[0].Ciphertext = "00" + ciphertext[2:]
+			}
+		}
+		bMsg, err := msg.Marshal()
+		if err != nil {
+			t.Fatalf("Error encoding deal message: %v\n", err)
+		}
+		log = append(log, bMsg)
+	}
+	// every participant reads the log and decrypts its shares
+	for _, bMsg := range log {
+		msg, err := UnmarshalDealMessage(bMsg)
+		if err != nil {
+			t.Fatalf("Error decoding deal message: %v\n", err)
+		}
+		for _, pt := range participants {
+			if pt.Index() == msg.Dealer {
+				continue
+			}
+			// an undecryptable share is also reported by Verify
+			if err := pt.ReceiveMessage(msg); err != nil {
+				t.Logf("Error receiving deal message: %v\n", err)
+			}
+		}
+	}
+	complaints := []Complaint{}
+	for _, pt := range participants {
+		c, err := pt.Verify()
+		if err != nil {
+			t.Fatalf("Error verifying: %v\n", err)
+		}
+		complaints = append(complaints, c...)
+	}
+	if len(complaints) != 1 || complaints[0] != (Complaint{Complainer: 1, Dealer: 3}) {
+		t.Fatalf("Unexpected complaints: %v\n", complaints)
+	}
+	// the dealer 3 justifies the share, so it qualifies
+	justifications, err := participants[2].Justify(complaints)
+	if err != nil {
+		t.Fatalf("Error justifying: %v\n", err)
+	}
+	keyShares := make([]*KeyShare, len(participants))
+	for i, pt := range participants {
+		if keyShares[i], err = pt.Finalize(complaints, justifications); err != nil {
+			t.Fatalf("Error finalizing: %v\n", err)
+		}
+	}
+	checkKeyShares(t, params, keyShares, []int{1, 2, 3, 4, 5})
+}
+
+func TestEncryptShare(t *testing.T) {
+	params := testParams(FFDHE2048, 2, 1)
+	pt, err := NewParticipant(rand.Reader, params, 2)
+	if err != nil {
+		t.Fatalf("Error creating participant: %v\n", err)
+	}
+	share := new(big.Int).Sub(params.Group.Order(), big.NewInt(1))
+	encShare, err := EncryptShare(rand.Reader, params, pt.EncryptionKey(), 1, 2, share)
+	if err != nil {
+		t.Fatalf("Error encrypting share: %v\n", err)
+	}
+	decShare, err := DecryptShare(params, pt.encSecret, 1, *encShare)
+	if err != nil {
+		t.Fatalf("Error decrypting share: %v\n", err)
+	}
+	if decShare.Cmp(share) != 0 {
+		t.Errorf("Unexpected share: expected %s, got %s\n", share, decShare)
+	}
+	// the key stream is bound to the dealer index
+	if decShare, err := DecryptShare(params, pt.encSecret, 3, *encShare); err == nil && decShare.Cmp(share) == 0 {
+		t.Error("Expected a different share decrypting with another dealer index")
+	}
+	// encryption keys must be in the group
+	if _, err := pt.DealMessage([]*big.Int{big.NewInt(1), pt.EncryptionKey()}); err == nil {
+		t.Error("Expected error dealing with an invalid encryption key")
+	}
+}
@@ -0,0 +1,89 @@
+package dkg
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vocdoni/paillier-sandbox/internal/subset"
+)
+
+// RecoverSecret reconstructs the secret shared with a polynomial of degree
+// threshold-1 from the shares of the participants with the indices provided,
+// tolerating bad shares. Repeated indices are skipped, keeping the first
+// share, and an index out of range or a share out of [0, q) is an error. The
+// subsets of threshold shares are tried in order until the polynomial
+// through one of them agrees with at least (n+t)/2 of the n shares, which
+// makes the secret unique while less than (n-t)/2 shares are bad. So the
+// secret of the first subset is cross-checked with the other shares, and with
+// 3t shares or more with a disjoint subset.
+func RecoverSecret(params Params, shares []*big.Int, indices []int) (*big.Int, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if len(shares) != len(indices) {
+		return nil, fmt.Errorf("got %d shares and %d indices", len(shares), len(indices))
+	}
+	q := params.Group.Order()
+	var uniqueShares []*big.Int
+	var uniqueIndices []int
+	seen := make(map[int]bool, len(indices))
+	for i, idx := range indices {
+		if idx < 1 || idx > params.Participants {
+			return nil, fmt.Errorf("share %d: index must be between 1 and %d, got %d", i, params.Participants, idx)
+		}
+		if shares[i] == nil || shares[i].Sign() < 0 || shares[i].Cmp(q) >= 0 {
+			return nil, fmt.Errorf("share %d must be between 0 and q", i)
+		}
+		if seen[idx] {
+			continue
+		}
+		seen[idx] = true
+		uniqueShares = append(uniqueShares, shares[i])
+		uniqueIndices = append(uniqueIndices, idx)
+	}
+	n, t := len(uniqueShares), params.Threshold
+	if n < t {
+		return nil, fmt.Errorf("needed %d shares, got %d", t, n)
+	}
+	minAgreement := (n + t + 1) / 2
+	positions := subset.First(t)
+	subsetShares := make([]*big.Int, t)
+	subsetIndices := make([]int, t)
+	for tries := 0; tries < subset.MaxTries; tries++ {
+		for i, j := range positions {
+			subsetShares[i], subsetIndices[i] = uniqueShares[j], uniqueIndices[j]
+		}
+		agreement := 0
+		for j, idx := range uniqueIndices {
+			value, err := evalInterpolation(subsetShares, subsetIndices, idx, q)
+			if err != nil {
+				return nil, err
+			}
+			if value.Cmp(uniqueShares[j]) == 0 {
+				agreement++
+			}
+		}
+		if agreement >= minAgreement {
+			return evalInterpolation(subsetShares, subsetIndices, 0, q)
+		}
+		if !subset.Next(positions, n) {
+			return nil, fmt.Errorf("no polynomial agrees with %d of the %d shares", minAgreement, n)
+		}
+	}
+	return nil, fmt.Errorf("no polynomial agrees with %d of the %d shares in the first %d subsets tried",
+		minAgreement, n, subset.MaxTries)
+}
+
+// evalInterpolation evaluates at x the polynomial that interpolates the
+// shares of the participants with the indices provided.
+func evalInterpolation(shares []*big.Int, indices []int, x int, q *big.Int) (*big.Int, error) {
+	value := big.NewInt(0)
+	for i, share := range shares {
+		lambda, err := lagrangeCoefficientAt(indices[i], x, indices, q)
+		if err != nil {
+			return nil, err
+		}
+		value.Add(value, lambda.Mul(lambda, share)).Mod(value, q)
+	}
+	return value, nil
+}
@@ -0,0 +1,63 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestRecoverSecret(t *testing.T) {
+	params := testParams(BabyJubJub, 7, 3)
+	q := params.Group.Order()
+	coeffs, err := GenerateRandomPolynomial(rand.Reader, params.Threshold, q)
+	if err != nil {
+		t.Fatalf("Error generating polynomial: %v\n", err)
+	}
+	shares := make([]*big.Int, params.Participants)
+	indices := make([]int, params.Participants)
+	for i := range shares {
+		indices[i] = i + 1
+		if shares[i], err = GenerateShare(i+1, coeffs, q); err != nil {
+			t.Fatalf("Error generating share: %v\n", err)
+		}
+	}
+	checkSecret := func(name string, shares []*big.Int, indices []int) {
+		secret, err := RecoverSecret(params, shares, indices)
+		if err != nil {
+			t.Fatalf("Error recovering secret %s: %v\n", name, err)
+		}
+		if secret.Cmp(coeffs[0]) != 0 {
+			t.Errorf("Unexpected secret %s: expected %s, got %s\n", name, coeffs[0], secret)
+		}
+	}
+	checkSecret("with threshold shares", shares[4:], indices[4:])
+	checkSecret("with repeated indices",
+		[]*big.Int{shares[1], shares[1], shares[5], shares[1], shares[2]}, []int{2, 2, 6, 2, 3})
+	// 7 shares agree with the polynomial of a subset with up to 2 bad ones
+	bad := make([]*big.Int, len(shares))
+	copy(bad, shares)
+	bad[0] = new(big.Int).Add(shares[0], big.NewInt(1))
+	bad[2] = big.NewInt(0)
+	checkSecret("with bad shares", bad, indices)
+	bad[3] = big.NewInt(0)
+	if _, err := RecoverSecret(params, bad, indices); err == nil {
+		t.Error("Expected error recovering a secret with too many bad shares")
+	}
+	// invalid input is an error instead of a panic
+	for _, tc := range []struct {
+		shares  []*big.Int
+		indices []int
+	}{
+		{shares[:3], indices[:2]},
+		{shares[:2], indices[:2]},
+		{shares[:3], []int{1, 2, 8}},
+		{shares[:3], []int{0, 1, 2}},
+		{[]*big.Int{shares[0], shares[1], q}, indices[:3]},
+		{[]*big.Int{shares[0], shares[1], nil}, indices[:3]},
+		{shares[:3], []int{1, 1, 2}},
+	} {
+		if _, err := RecoverSecret(params, tc.shares, tc.indices); err == nil {
+			t.Errorf("Expected error recovering secret with indices %v\n", tc.indices)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package dkg
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+)
+
+// batchCoefficientBits is the size of the random coefficients of the linear
+// combination of BatchVerifyShares, an invalid share passes the batch with
+// probability 2^-batchCoefficientBits.
+const batchCoefficientBits = 128
+
+// Commitment is a Feldman commitment C = g^a to a coefficient a of the
+// polynomial of a dealer, built by GenerateCommitments or received and
+// validated with NewCommitment, which checks that C is an element of the
+// subgroup of order q of its group: for a MODP group C != 0 and C^q = 1 mod p,
+// for BabyJubJub a point of the prime order subgroup.
+type Commitment struct {
+	group Group
+	value *big.Int
+}
+
+// NewCommitment validates the commitment c in the group provided.
+func NewCommitment(group Group, c *big.Int) (*Commitment, error) {
+	if group == nil {
+		return nil, fmt.Errorf("missing group")
+	}
+	if c == nil || c.Sign() == 0 {
+		return nil, fmt.Errorf("commitment must not be zero")
+	}
+	if !group.IsElement(c) {
+		return nil, fmt.Errorf("commitment is not in the subgroup of order q of %s", group.Name())
+	}
+	return &Commitment{group: group, value: new(big.Int).Set(c)}, nil
+}
+
+// NewCommitments validates every commitment provided, see NewCommitment.
+func NewCommitments(group Group, values []*big.Int) ([]*Commitment, error) {
+	commitments := make([]*Commitment, len(values))
+	for i, c := range values {
+		var err error
+		if commitments[i], err = NewCommitment(group, c); err != nil {
+			return nil, fmt.Errorf("commitment %d: %w", i, err)
+		}
+	}
+	return commitments, nil
+}
+
+// Group returns the group of the commitment.
+func (c *Commitment) Group() Group {
+	return c.group
+}
+
+// Value returns a copy of the group element of the commitment.
+func (c *Commitment) Value() *big.Int {
+	return new(big.Int).Set(c.value)
+}
+
+// String returns the decimal representation of the commitment.
+func (c *Commitment) String() string {
+	return c.value.String()
+}
+
+// checkCommitments checks that there is at least one commitment and that all
+// of them were built with NewCommitment in the group provided, comparing the
+// parameters of the groups and not only their names.
+func checkCommitments(group Group, commitments []*Commitment) error {
+	if len(commitments) == 0 {
+		return fmt.Errorf("no commitments")
+	}
+	for i, c := range commitments {
+		if c == nil || c.group == nil || c.value == nil {
+			return fmt.Errorf("commitment %d was not validated", i)
+		}
+		if !sameGroup(c.group, group) {
+			return fmt.Errorf("commitment %d is of group %s, expected %s", i, c.group.Name(), group.Name())
+		}
+	}
+	return nil
+}
+
+// DealtShare is the share that a dealer sent to a recipient, with the
+// commitments of the dealer to check it.
+type DealtShare struct {
+	Dealer      int
+	Recipient   int
+	Share       *big.Int
+	Commitments []*Commitment
+}
+
+// BatchVerifyShares verifies all the shares provided, from any number of
+// dealers, at once with a random linear combination: for random r_t,
+// g^(sum r_t*s_t) = prod_d prod_k C_dk^(sum_{t of d} r_t*i_t^k). It takes an
+// exponentiation per commitment of every dealer, instead of one per
+// commitment of every share. The coefficients are read from the randomness
+// source provided. It returns the positions of the invalid shares, which are
+// found verifying the shares one by one when the combination does not match.
+// The shares of a dealer must have the same commitments.
+func BatchVerifyShares(random io.Reader, group Group, shares []*DealtShare) ([]int, error) {
+	if group == nil {
+		return nil, fmt.Errorf("missing group")
+	}
+	q := group.Order()
+	bound := new(big.Int).Lsh(big.NewInt(1), batchCoefficientBits)
+	// the exponents of g and of the commitments of every dealer
+	gExp := big.NewInt(0)
+	dealerExps := make(map[int][]*big.Int)
+	dealerCommitments := make(map[int][]*Commitment)
+	var invalid, batched []int
+	for t, ds := range shares {
+		if ds == nil || ds.Recipient < 1 || ds.Share == nil || ds.Share.Sign() < 0 || ds.Share.Cmp(q) >= 0 ||
+			checkCommitments(group, ds.Commitments) != nil {
+			invalid = append(invalid, t)
+			continue
+		}
+		if commitments, ok := dealerCommitments[ds.Dealer]; !ok {
+			dealerCommitments[ds.Dealer] = ds.Commitments
+			dealerExps[ds.Dealer] = make([]*big.Int, len(ds.Commitments))
+			for k := range dealerExps[ds.Dealer] {
+				dealerExps[ds.Dealer][k] = big.NewInt(0)
+			}
+		} else if !sameCommitments(commitments, ds.Commitments) {
+			return nil, fmt.Errorf("different commitments of dealer %d", ds.Dealer)
+		}
+		r, err := randomInt(random, bound)
+		if err != nil {
+			return nil, err
+		}
+		gExp.Add(gExp, new(big.Int).Mul(r, ds.Share)).Mod(gExp, q)
+		// r * i^k for every k
+		x := big.NewInt(int64(ds.Recipient))
+		term := r
+		for _, exp := range dealerExps[ds.Dealer] {
+			exp.Add(exp, term).Mod(exp, q)
+			term = new(big.Int).Mul(term, x)
+			term.Mod(term, q)
+		}
+		batched = append(batched, t)
+	}
+	rhs := group.Identity()
+	for dealer, exps := range dealerExps {
+		for k, exp := range exps {
+			rhs = group.Mul(rhs, group.Exp(dealerCommitments[dealer][k].value, exp))
+		}
+	}
+	if group.Exp(group.Generator(), gExp).Cmp(rhs) == 0 {
+		return invalid, nil
+	}
+	for _, t := range batched {
+		ds := shares[t]
+		if VerifyShare(group, ds.Share, ds.Recipient, ds.Commitments) != nil {
+			invalid = append(invalid, t)
+		}
+	}
+	slices.Sort(invalid)
+	return invalid, nil
+}
+
+// sameCommitments checks that both lists have the same commitments.
+func sameCommitments(a, b []*Commitment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].value.Cmp(b[i].value) != 0 {
+			return false
+		}
+	}
+	return true
+}
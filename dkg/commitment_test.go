@@ -0,0 +1,115 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestNewCommitment(t *testing.T) {
+	for _, group := range []Group{BabyJubJub, FFDHE2048} {
+		c, err := NewCommitment(group, group.Generator())
+		if err != nil {
+			t.Fatalf("Error creating commitment: %v\n", err)
+		}
+		if c.Value().Cmp(group.Generator()) != 0 || c.Group().Name() != group.Name() {
+			t.Errorf("Unexpected commitment %s in %s\n", c, c.Group().Name())
+		}
+	}
+	// p - 1 has order 2, so (p - 1)^q != 1 mod p
+	p := FFDHE2048.Modulus()
+	for _, x := range []*big.Int{nil, big.NewInt(0), new(big.Int).Sub(p, big.NewInt(1)), p} {
+		if _, err := NewCommitment(FFDHE2048, x); err == nil {
+			t.Errorf("Expected error creating commitment %v\n", x)
+		}
+	}
+	if _, err := NewCommitment(BabyJubJub, big.NewInt(2)); err == nil {
+		t.Error("Expected error creating a commitment out of the babyjubjub subgroup")
+	}
+	if _, err := NewCommitments(BabyJubJub, []*big.Int{BabyJubJub.Generator(), big.NewInt(0)}); err == nil {
+		t.Error("Expected error creating commitments with an invalid one")
+	}
+}
+
+func TestCommitmentGroup(t *testing.T) {
+	// custom groups of the same size have different names, and the
+	// commitments of one are rejected in the other
+	a, err := NewMODPGroup(FFDHE2048.Modulus(), FFDHE2048.Generator())
+	if err != nil {
+		t.Fatalf("Error creating group: %v\n", err)
+	}
+	b, err := NewMODPGroup(RFC3526Group2048.Modulus(), RFC3526Group2048.Generator())
+	if err != nil {
+		t.Fatalf("Error creating group: %v\n", err)
+	}
+	if a.Name() == b.Name() {
+		t.Errorf("Expected different names of custom groups, got %s\n", a.Name())
+	}
+	c, err := NewCommitment(a, a.Generator())
+	if err != nil {
+		t.Fatalf("Error creating commitment: %v\n", err)
+	}
+	if err := checkCommitments(b, []*Commitment{c}); err == nil {
+		t.Error("Expected error checking a commitment of another custom group")
+	}
+	// the same parameters are the same group
+	if err := checkCommitments(FFDHE2048, []*Commitment{c}); err != nil {
+		t.Errorf("Error checking a commitment of the same group: %v\n", err)
+	}
+}
+
+func TestBatchVerifyShares(t *testing.T) {
+	group := BabyJubJub
+	q := group.Order()
+	dealers, recipients, threshold := 4, 6, 3
+	var shares []*DealtShare
+	for dealer := 1; dealer <= dealers; dealer++ {
+		coeffs, err := GenerateRandomPolynomial(rand.Reader, threshold, q)
+		if err != nil {
+			t.Fatalf("Error generating polynomial: %v\n", err)
+		}
+		commitments, err := GenerateCommitments(group, coeffs)
+		if err != nil {
+			t.Fatalf("Error generating commitments: %v\n", err)
+		}
+		for recipient := 1; recipient <= recipients; recipient++ {
+			share, err := GenerateShare(recipient, coeffs, q)
+			if err != nil {
+				t.Fatalf("Error generating share: %v\n", err)
+			}
+			shares = append(shares, &DealtShare{
+				Dealer:      dealer,
+				Recipient:   recipient,
+				Share:       share,
+				Commitments: commitments,
+			})
+		}
+	}
+	invalid, err := BatchVerifyShares(rand.Reader, group, shares)
+	if err != nil {
+		t.Fatalf("Error verifying shares: %v\n", err)
+	}
+	if len(invalid) != 0 {
+		t.Errorf("Unexpected invalid shares %v\n", invalid)
+	}
+	// a wrong share, a share out of range and a share to a wrong recipient
+	shares[3].Share = new(big.Int).Add(shares[3].Share, big.NewInt(1))
+	shares[10].Share = new(big.Int).Add(shares[10].Share, q)
+	shares[20].Recipient = 7
+	invalid, err = BatchVerifyShares(rand.Reader, group, append(shares, nil))
+	if err != nil {
+		t.Fatalf("Error verifying shares: %v\n", err)
+	}
+	if fmt.Sprint(invalid) != fmt.Sprint([]int{3, 10, 20, len(shares)}) {
+		t.Errorf("Unexpected invalid shares %v\n", invalid)
+	}
+	// the shares of a dealer must have the same commitments
+	shares[1] = &DealtShare{Dealer: 1, Recipient: 2, Share: shares[1].Share, Commitments: shares[6].Commitments}
+	if _, err := BatchVerifyShares(rand.Reader, group, shares); err == nil {
+		t.Error("Expected error verifying shares with different commitments of a dealer")
+	}
+	if _, err := BatchVerifyShares(nil, group, shares[:1]); err == nil {
+		t.Error("Expected error verifying shares without randomness")
+	}
+}
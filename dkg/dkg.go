@@ -2,31 +2,56 @@
 package dkg
 
 import (
+	"context"
 	"crypto/rand"
+	"fmt"
+	"io"
 	"math/big"
 )
 
-// GeneratePolynomial generates a random polynomial of degree k-1 with zero constant term.
-func GeneratePolynomial(k int, q *big.Int) []*big.Int {
+// GeneratePolynomial generates a random polynomial of degree k-1 with zero
+// constant term, reading the coefficients from the randomness source provided.
+func GeneratePolynomial(random io.Reader, k int, q *big.Int) ([]*big.Int, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("degree must be positive, got %d", k-1)
+	}
 	coeffs := make([]*big.Int, k)
 	coeffs[0] = big.NewInt(0) // Zero constant term
 	for i := 1; i < k; i++ {
-		coeffs[i], _ = rand.Int(rand.Reader, q) // Random coefficients modulo q
+		var err error
+		if coeffs[i], err = randomInt(random, q); err != nil { // Random coefficients modulo q
+			return nil, err
+		}
 	}
-	return coeffs
+	return coeffs, nil
 }
 
-// GenerateCommitments generates commitments for the polynomial coefficients.
-func GenerateCommitments(coeffs []*big.Int, g, p *big.Int) []*big.Int {
-	commitments := make([]*big.Int, len(coeffs))
+// GenerateCommitments generates commitments for the polynomial coefficients
+// in the group provided, which must be in [0, q).
+func GenerateCommitments(group Group, coeffs []*big.Int) ([]*Commitment, error) {
+	if group == nil {
+		return nil, fmt.Errorf("missing group")
+	}
+	if err := checkCoefficients(coeffs, group.Order()); err != nil {
+		return nil, err
+	}
+	commitments := make([]*Commitment, len(coeffs))
+	g := group.Generator()
 	for i, coeff := range coeffs {
-		commitments[i] = new(big.Int).Exp(g, coeff, p) // C_i = g^{a_i} mod p
+		commitments[i] = &Commitment{group: group, value: group.Exp(g, coeff)} // C_i = g^{a_i}
 	}
-	return commitments
+	return commitments, nil
 }
 
 // GenerateShare computes the share for participant i using the polynomial.
-func GenerateShare(i int, coeffs []*big.Int, q *big.Int) *big.Int {
+// The index must be positive, since the share of 0 is the secret.
+func GenerateShare(i int, coeffs []*big.Int, q *big.Int) (*big.Int, error) {
+	if i < 1 {
+		return nil, fmt.Errorf("index must be positive, got %d", i)
+	}
+	if err := checkCoefficients(coeffs, q); err != nil {
+		return nil, err
+	}
 	x := big.NewInt(int64(i))
 	share := big.NewInt(0)
 	for j := 0; j < len(coeffs); j++ {
@@ -36,81 +61,146 @@ func GenerateShare(i int, coeffs []*big.Int, q *big.Int) *big.Int {
 		term := new(big.Int).Mul(coeffs[j], xExpJ)
 		share.Add(share, term).Mod(share, q)
 	}
-	return share
+	return share, nil
 }
 
-// VerifyShare verifies a share using the public commitments.
-func VerifyShare(share *big.Int, i int, commitments []*big.Int, g, p *big.Int) bool {
-	lhs := new(big.Int).Exp(g, share, p) // lhs = g^{s_i} mod p
-
-	rhs := big.NewInt(1)
-	x := big.NewInt(int64(i))
-	for j := 0; j < len(commitments); j++ {
-		exp := new(big.Int).Exp(x, big.NewInt(int64(j)), p)
-		commitmentExp := new(big.Int).Exp(commitments[j], exp, p)
-		rhs.Mul(rhs, commitmentExp).Mod(rhs, p)
+// VerifyShare verifies the share of the participant i using the public
+// commitments in the group provided, and returns an error if the share is not
+// in [0, q) or does not match. To verify many shares use BatchVerifyShares.
+func VerifyShare(group Group, share *big.Int, i int, commitments []*Commitment) error {
+	if group == nil {
+		return fmt.Errorf("missing group")
+	}
+	if share == nil || share.Sign() < 0 || share.Cmp(group.Order()) >= 0 {
+		return fmt.Errorf("share must be between 0 and q")
 	}
+	if i < 1 {
+		return fmt.Errorf("index must be positive, got %d", i)
+	}
+	if err := checkCommitments(group, commitments); err != nil {
+		return err
+	}
+	lhs := group.Exp(group.Generator(), share) // lhs = g^{s_i}
+
+	rhs := evalCommitments(group, commitments, i)
 
 	// Check if lhs == rhs
-	return lhs.Cmp(rhs) == 0
+	if lhs.Cmp(rhs) != 0 {
+		return fmt.Errorf("share of participant %d does not match the commitments", i)
+	}
+	return nil
 }
 
-// LagrangeInterpolation reconstructs the secret using the provided shares and indices.
-func LagrangeInterpolation(shares []*big.Int, indices []int, q *big.Int) *big.Int {
+// LagrangeInterpolation reconstructs the secret using the provided shares and
+// indices, which must be distinct and positive.
+func LagrangeInterpolation(shares []*big.Int, indices []int, q *big.Int) (*big.Int, error) {
+	if len(shares) == 0 || len(shares) != len(indices) {
+		return nil, fmt.Errorf("got %d shares and %d indices", len(shares), len(indices))
+	}
+	seen := make(map[int]bool, len(indices))
+	for i, idx := range indices {
+		if idx < 1 {
+			return nil, fmt.Errorf("index must be positive, got %d", idx)
+		}
+		if seen[idx] {
+			return nil, fmt.Errorf("repeated index %d", idx)
+		}
+		seen[idx] = true
+		if shares[i] == nil {
+			return nil, fmt.Errorf("nil share %d", i)
+		}
+	}
 	secret := big.NewInt(0)
 	for i := 0; i < len(shares); i++ {
-		numerator := big.NewInt(1)
-		denominator := big.NewInt(1)
-		xi := big.NewInt(int64(indices[i]))
-		for j := 0; j < len(shares); j++ {
-			if i != j {
-				xj := big.NewInt(int64(indices[j]))
-				numerator.Mul(numerator, new(big.Int).Neg(xj)).Mod(numerator, q) // numerator *= -xj
-				diff := new(big.Int).Sub(xi, xj)                                 // xi - xj
-				denominator.Mul(denominator, diff).Mod(denominator, q)           // denominator *= xi - xj
-			}
-		}
-		// Compute inverse of denominator modulo q
-		invDenominator := new(big.Int).ModInverse(denominator, q)
-		if invDenominator == nil {
-			panic("Denominator has no inverse modulo q")
+		lagrangeCoeff, err := LagrangeCoefficient(indices[i], indices, q)
+		if err != nil {
+			return nil, err
 		}
-		lagrangeCoeff := new(big.Int).Mul(numerator, invDenominator)
-		lagrangeCoeff.Mod(lagrangeCoeff, q)
-
 		term := new(big.Int).Mul(shares[i], lagrangeCoeff)
 		secret.Add(secret, term).Mod(secret, q)
 	}
-	return secret
+	return secret, nil
+}
+
+// LagrangeCoefficient returns the Lagrange coefficient at 0 of the index i
+// among the indices provided, prod(-x_j / (x_i - x_j)) mod q, to combine
+// shares or values in the exponent like partial decryptions.
+func LagrangeCoefficient(i int, indices []int, q *big.Int) (*big.Int, error) {
+	return lagrangeCoefficientAt(i, 0, indices, q)
 }
 
-// GenerateSafePrime generates a safe prime p and its corresponding q such that p = 2q + 1.
-func GenerateSafePrime(bits int) (q, p *big.Int) {
+// lagrangeCoefficientAt returns the Lagrange coefficient at x of the index i
+// among the indices provided, prod((x - x_j) / (x_i - x_j)) mod q.
+func lagrangeCoefficientAt(i, x int, indices []int, q *big.Int) (*big.Int, error) {
+	if q == nil || q.Cmp(big.NewInt(1)) <= 0 {
+		return nil, fmt.Errorf("invalid modulus")
+	}
+	numerator := big.NewInt(1)
+	denominator := big.NewInt(1)
+	xi := big.NewInt(int64(i))
+	for _, j := range indices {
+		if j == i {
+			continue
+		}
+		xj := big.NewInt(int64(j))
+		numerator.Mul(numerator, big.NewInt(int64(x)-int64(j))).Mod(numerator, q) // numerator *= x - xj
+		diff := new(big.Int).Sub(xi, xj)                                          // xi - xj
+		denominator.Mul(denominator, diff).Mod(denominator, q)                    // denominator *= xi - xj
+	}
+	// Compute inverse of denominator modulo q
+	invDenominator := new(big.Int).ModInverse(denominator, q)
+	if invDenominator == nil {
+		return nil, fmt.Errorf("denominator has no inverse modulo q")
+	}
+	return numerator.Mul(numerator, invDenominator).Mod(numerator, q), nil
+}
+
+// GenerateSafePrime generates a safe prime p of the bits provided and its
+// corresponding q such that p = 2q + 1, reading the candidates from the
+// randomness source provided. The search can take long for large sizes, it
+// stops with the error of the context when it is done.
+func GenerateSafePrime(ctx context.Context, random io.Reader, bits int) (q, p *big.Int, err error) {
+	if bits < 3 {
+		return nil, nil, fmt.Errorf("safe primes must have at least 3 bits, got %d", bits)
+	}
+	if random == nil {
+		return nil, nil, fmt.Errorf("missing randomness source")
+	}
 	one := big.NewInt(1)
 	two := big.NewInt(2)
 	for {
-		qCandidate, err := rand.Prime(rand.Reader, bits-1) // q has bits-1 bits
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		qCandidate, err := rand.Prime(random, bits-1) // q has bits-1 bits
 		if err != nil {
-			panic("Failed to generate random prime q")
+			return nil, nil, fmt.Errorf("failed to generate random prime q: %w", err)
 		}
 		pCandidate := new(big.Int).Mul(qCandidate, two)
 		pCandidate.Add(pCandidate, one) // p = 2q + 1
 
 		if pCandidate.ProbablyPrime(20) {
-			return qCandidate, pCandidate
+			return qCandidate, pCandidate, nil
 		}
 		// Else, try again
 	}
 }
 
-// FindGenerator finds a generator g of the subgroup of order q in Z_p^*.
-func FindGenerator(p, q *big.Int) *big.Int {
+// FindGenerator finds a generator g of the subgroup of order q in Z_p^*,
+// where p = 2q + 1 must be a safe prime.
+func FindGenerator(random io.Reader, p, q *big.Int) (*big.Int, error) {
 	one := big.NewInt(1)
+	if p == nil || q == nil || p.Cmp(new(big.Int).Add(new(big.Int).Lsh(q, 1), one)) != 0 {
+		return nil, fmt.Errorf("p must be 2q + 1")
+	}
+	if q.Cmp(big.NewInt(2)) < 0 || !q.ProbablyPrime(20) || !p.ProbablyPrime(20) {
+		return nil, fmt.Errorf("p must be a safe prime")
+	}
 	for {
 		// Randomly pick h in [2, p-2]
-		h, err := rand.Int(rand.Reader, new(big.Int).Sub(p, big.NewInt(3)))
+		h, err := randomInt(random, new(big.Int).Sub(p, big.NewInt(3)))
 		if err != nil {
-			panic("Failed to generate random h")
+			return nil, fmt.Errorf("failed to generate random h: %w", err)
 		}
 		h.Add(h, big.NewInt(2)) // Ensure h >= 2
 
@@ -119,7 +209,36 @@ func FindGenerator(p, q *big.Int) *big.Int {
 
 		// Check if g^q mod p == 1 (i.e., g ∈ subgroup of order q)
 		if new(big.Int).Exp(g, q, p).Cmp(one) == 0 && g.Cmp(one) != 0 {
-			return g
+			return g, nil
+		}
+	}
+}
+
+// randomInt returns a uniform random value in [0, bound) read from the
+// randomness source provided.
+func randomInt(random io.Reader, bound *big.Int) (*big.Int, error) {
+	if random == nil {
+		return nil, fmt.Errorf("missing randomness source")
+	}
+	if bound == nil || bound.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid random bound")
+	}
+	return rand.Int(random, bound)
+}
+
+// checkCoefficients checks that the polynomial has at least one coefficient
+// and all of them are in [0, q).
+func checkCoefficients(coeffs []*big.Int, q *big.Int) error {
+	if len(coeffs) == 0 {
+		return fmt.Errorf("empty polynomial")
+	}
+	if q == nil || q.Sign() <= 0 {
+		return fmt.Errorf("invalid modulus")
+	}
+	for i, coeff := range coeffs {
+		if coeff == nil || coeff.Sign() < 0 || coeff.Cmp(q) >= 0 {
+			return fmt.Errorf("coefficient %d must be between 0 and q", i)
 		}
 	}
+	return nil
 }
@@ -2,6 +2,8 @@
 package dkg
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
 	"math/big"
 	"testing"
@@ -9,10 +11,15 @@ import (
 
 func TestDKG(t *testing.T) {
 	// Parameters
-	bits := 512                     // Bit length for primes
-	q, p := GenerateSafePrime(bits) // Generate safe prime p and corresponding q
-	k := 3                          // Threshold
-	nParties := 5                   // Number of parties
+	bits := 512   // Bit length for primes
+	k := 3        // Threshold
+	nParties := 5 // Number of parties
+
+	// Generate safe prime p and corresponding q
+	q, p, err := GenerateSafePrime(context.Background(), rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("Error generating safe prime: %v\n", err)
+	}
 
 	// Verify that p is prime (should always be true here)
 	if !p.ProbablyPrime(20) {
@@ -20,19 +27,31 @@ func TestDKG(t *testing.T) {
 	}
 
 	// Find generator g of subgroup of order q
-	g := FindGenerator(p, q)
+	g, err := FindGenerator(rand.Reader, p, q)
+	if err != nil {
+		t.Fatalf("Error finding generator: %v\n", err)
+	}
 
 	fmt.Printf("Prime p: %s\n", p.String())
 	fmt.Printf("Prime q: %s\n", q.String())
 	fmt.Printf("Generator g: %s\n", g.String())
 
+	group, err := NewMODPGroup(p, g)
+	if err != nil {
+		t.Fatalf("Error creating group: %v\n", err)
+	}
+
 	// Each party generates its polynomial and commitments
 	polynomials := make([][]*big.Int, nParties)
-	commitments := make([][]*big.Int, nParties)
+	commitments := make([][]*Commitment, nParties)
 
 	for i := 0; i < nParties; i++ {
-		polynomials[i] = GeneratePolynomial(k, q)
-		commitments[i] = GenerateCommitments(polynomials[i], g, p)
+		if polynomials[i], err = GeneratePolynomial(rand.Reader, k, q); err != nil {
+			t.Fatalf("Error generating polynomial: %v\n", err)
+		}
+		if commitments[i], err = GenerateCommitments(group, polynomials[i]); err != nil {
+			t.Fatalf("Error generating commitments: %v\n", err)
+		}
 	}
 
 	// Each party generates shares for all other parties
@@ -40,7 +59,9 @@ func TestDKG(t *testing.T) {
 	for i := 0; i < nParties; i++ {
 		shares[i] = make([]*big.Int, nParties)
 		for j := 0; j < nParties; j++ {
-			shares[i][j] = GenerateShare(j+1, polynomials[i], q)
+			if shares[i][j], err = GenerateShare(j+1, polynomials[i], q); err != nil {
+				t.Fatalf("Error generating share: %v\n", err)
+			}
 		}
 	}
 
@@ -48,9 +69,8 @@ func TestDKG(t *testing.T) {
 	for i := 0; i < nParties; i++ {
 		for j := 0; j < nParties; j++ {
 			// Party i verifies share from party j
-			valid := VerifyShare(shares[j][i], i+1, commitments[j], g, p)
-			if !valid {
-				t.Fatalf("Share verification failed for party %d's share from party %d", i+1, j+1)
+			if err := VerifyShare(group, shares[j][i], i+1, commitments[j]); err != nil {
+				t.Fatalf("Share verification failed for party %d's share from party %d: %v", i+1, j+1, err)
 			}
 		}
 	}
@@ -73,7 +93,10 @@ func TestDKG(t *testing.T) {
 	}
 
 	// Reconstruct the secret
-	secret := LagrangeInterpolation(subsetShares, indices, q)
+	secret, err := LagrangeInterpolation(subsetShares, indices, q)
+	if err != nil {
+		t.Fatalf("Error reconstructing secret: %v\n", err)
+	}
 
 	// Since all polynomials had zero constant term, the secret should be zero
 	if secret.Cmp(big.NewInt(0)) != 0 {
@@ -83,3 +106,84 @@ func TestDKG(t *testing.T) {
 		fmt.Printf("Secret successfully reconstructed: %s\n", secret.String())
 	}
 }
+
+func TestInvalidInput(t *testing.T) {
+	// the prime search stops when the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := GenerateSafePrime(ctx, rand.Reader, 4096); err != context.Canceled {
+		t.Errorf("Expected context canceled generating a safe prime, got %v\n", err)
+	}
+	if _, _, err := GenerateSafePrime(context.Background(), rand.Reader, 2); err == nil {
+		t.Error("Expected error generating a safe prime too small")
+	}
+	if _, _, err := GenerateSafePrime(context.Background(), nil, 64); err == nil {
+		t.Error("Expected error generating a safe prime without randomness")
+	}
+	// 13 = 2*6 + 1 is prime but 6 is not
+	if _, err := FindGenerator(rand.Reader, big.NewInt(13), big.NewInt(6)); err == nil {
+		t.Error("Expected error finding a generator of a prime that is not safe")
+	}
+	if _, err := FindGenerator(rand.Reader, big.NewInt(23), big.NewInt(10)); err == nil {
+		t.Error("Expected error finding a generator with p != 2q + 1")
+	}
+	group := BabyJubJub
+	q := group.Order()
+	if _, err := GeneratePolynomial(rand.Reader, 0, q); err == nil {
+		t.Error("Expected error generating a polynomial of negative degree")
+	}
+	if _, err := GeneratePolynomial(nil, 3, q); err == nil {
+		t.Error("Expected error generating a polynomial without randomness")
+	}
+	coeffs, err := GeneratePolynomial(rand.Reader, 3, q)
+	if err != nil {
+		t.Fatalf("Error generating polynomial: %v\n", err)
+	}
+	commitments, err := GenerateCommitments(group, coeffs)
+	if err != nil {
+		t.Fatalf("Error generating commitments: %v\n", err)
+	}
+	if _, err := GenerateShare(0, coeffs, q); err == nil {
+		t.Error("Expected error generating the share of index 0")
+	}
+	if _, err := GenerateCommitments(group, []*big.Int{coeffs[0], q}); err == nil {
+		t.Error("Expected error generating commitments with a coefficient out of range")
+	}
+	share, err := GenerateShare(2, coeffs, q)
+	if err != nil {
+		t.Fatalf("Error generating share: %v\n", err)
+	}
+	if err := VerifyShare(group, share, 2, commitments); err != nil {
+		t.Errorf("Error verifying share: %v\n", err)
+	}
+	if err := VerifyShare(group, share, 3, commitments); err == nil {
+		t.Error("Expected error verifying the share of another participant")
+	}
+	if err := VerifyShare(group, share, 0, commitments); err == nil {
+		t.Error("Expected error verifying a share of index 0")
+	}
+	other, err := NewCommitment(FFDHE2048, FFDHE2048.Generator())
+	if err != nil {
+		t.Fatalf("Error creating commitment: %v\n", err)
+	}
+	if err := VerifyShare(group, share, 2, []*Commitment{commitments[0], other, commitments[2]}); err == nil {
+		t.Error("Expected error verifying a share with a commitment of another group")
+	}
+	if err := VerifyShare(group, q, 2, commitments); err == nil {
+		t.Error("Expected error verifying a share out of range")
+	}
+	// repeated indices are errors instead of panics
+	if _, err := LagrangeInterpolation([]*big.Int{share, share}, []int{2, 2}, q); err == nil {
+		t.Error("Expected error interpolating repeated indices")
+	}
+	if _, err := LagrangeInterpolation([]*big.Int{share}, []int{2, 3}, q); err == nil {
+		t.Error("Expected error interpolating with more indices than shares")
+	}
+	params := Params{Group: group, Participants: 3, Threshold: 2}
+	if _, err := EncryptShare(rand.Reader, params, group.Generator(), 1, 1, share); err == nil {
+		t.Error("Expected error encrypting a share to its dealer")
+	}
+	if _, err := EncryptShare(rand.Reader, params, group.Generator(), 1, 4, share); err == nil {
+		t.Error("Expected error encrypting a share to a recipient out of range")
+	}
+}
@@ -0,0 +1,321 @@
+package dkg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+)
+
+// Ciphertext is an exponential ElGamal ciphertext of a message m under the
+// public key y = g^x of a DKG: (C1, C2) = (g^r, g^m * y^r). The ciphertexts
+// are additively homomorphic, the product of two ciphertexts encrypts the sum
+// of their messages.
+type Ciphertext struct {
+	C1 *big.Int
+	C2 *big.Int
+}
+
+// PartialDecryption is the decryption share of a ciphertext of the
+// participant with the index provided, D = C1^x_i, with a Chaum-Pedersen
+// proof that log_g(g^x_i) = log_C1(D).
+type PartialDecryption struct {
+	Index int
+	D     *big.Int
+	Proof *DLEQProof
+}
+
+// DLEQProof is a non-interactive Chaum-Pedersen proof of equality of the
+// discrete logarithms of two elements in two bases, with the Fiat-Shamir
+// challenge and the response.
+type DLEQProof struct {
+	Challenge *big.Int
+	Response  *big.Int
+}
+
+// Encrypt encrypts the message provided, which must be in [0, q), with the
+// public key y and a random r read from the randomness source provided, and
+// returns the ciphertext and r.
+func Encrypt(random io.Reader, group Group, publicKey, m *big.Int) (*Ciphertext, *big.Int, error) {
+	r, err := RandomScalar(random, group)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := EncryptWithRandomness(group, publicKey, m, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, r, nil
+}
+
+// EncryptWithRandomness encrypts the message provided with the public key y
+// and the randomness r provided, (g^r, g^m * y^r).
+func EncryptWithRandomness(group Group, publicKey, m, r *big.Int) (*Ciphertext, error) {
+	if group == nil {
+		return nil, fmt.Errorf("missing group")
+	}
+	if m == nil || m.Sign() < 0 || m.Cmp(group.Order()) >= 0 {
+		return nil, fmt.Errorf("message must be between 0 and q")
+	}
+	if r == nil || r.Sign() <= 0 || r.Cmp(group.Order()) >= 0 {
+		return nil, fmt.Errorf("randomness must be between 1 and q")
+	}
+	if !group.IsElement(publicKey) || publicKey.Cmp(group.Identity()) == 0 {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	g := group.Generator()
+	return &Ciphertext{
+		C1: group.Exp(g, r),
+		C2: group.Mul(group.Exp(g, m), group.Exp(publicKey, r)),
+	}, nil
+}
+
+// AddCiphertexts returns the ciphertext of the sum of the messages of the
+// ciphertexts provided, that is the product of their components.
+func AddCiphertexts(group Group, ciphertexts ...*Ciphertext) (*Ciphertext, error) {
+	if group == nil {
+		return nil, fmt.Errorf("missing group")
+	}
+	if len(ciphertexts) == 0 {
+		return nil, fmt.Errorf("no ciphertexts to add")
+	}
+	sum := &Ciphertext{C1: group.Identity(), C2: group.Identity()}
+	for i, c := range ciphertexts {
+		if err := checkCiphertext(group, c); err != nil {
+			return nil, fmt.Errorf("invalid ciphertext %d: %w", i, err)
+		}
+		sum.C1 = group.Mul(sum.C1, c.C1)
+		sum.C2 = group.Mul(sum.C2, c.C2)
+	}
+	return sum, nil
+}
+
+// PartialDecrypt returns the decryption share of the ciphertext provided for
+// the key share, C1^x_i, with a proof that it matches the verification key
+// of the participant, made with a nonce read from the randomness source
+// provided.
+func (ks *KeyShare) PartialDecrypt(random io.Reader, group Group, c *Ciphertext) (*PartialDecryption, error) {
+	if group == nil {
+		return nil, fmt.Errorf("missing group")
+	}
+	if ks.Index < 1 || ks.Secret == nil || ks.Secret.Sign() < 0 || ks.Secret.Cmp(group.Order()) >= 0 {
+		return nil, fmt.Errorf("invalid key share")
+	}
+	if err := checkCiphertext(group, c); err != nil {
+		return nil, err
+	}
+	d := group.Exp(c.C1, ks.Secret)
+	// Chaum-Pedersen: commit to w, and respond z = w + e*x_i
+	w, err := RandomScalar(random, group)
+	if err != nil {
+		return nil, err
+	}
+	g := group.Generator()
+	verificationKey := group.Exp(g, ks.Secret)
+	e := dleqChallenge(group, ks.Index, verificationKey, c.C1, d, group.Exp(g, w), group.Exp(c.C1, w))
+	z := new(big.Int).Mul(e, ks.Secret)
+	z.Add(z, w).Mod(z, group.Order())
+	return &PartialDecryption{
+		Index: ks.Index,
+		D:     d,
+		Proof: &DLEQProof{Challenge: e, Response: z},
+	}, nil
+}
+
+// VerifyPartialDecryption checks the proof of the decryption share of the
+// ciphertext provided against the verification key of its participant,
+// g^x_i.
+func VerifyPartialDecryption(group Group, verificationKey *big.Int, c *Ciphertext, pd *PartialDecryption) error {
+	if group == nil {
+		return fmt.Errorf("missing group")
+	}
+	if pd == nil || pd.Proof == nil || pd.Proof.Challenge == nil || pd.Proof.Response == nil {
+		return fmt.Errorf("missing partial decryption proof")
+	}
+	if err := checkCiphertext(group, c); err != nil {
+		return err
+	}
+	if !group.IsElement(verificationKey) {
+		return fmt.Errorf("invalid verification key")
+	}
+	if !group.IsElement(pd.D) {
+		return fmt.Errorf("decryption share is not a group element")
+	}
+	q := group.Order()
+	e, z := pd.Proof.Challenge, pd.Proof.Response
+	if e.Sign() < 0 || e.Cmp(q) >= 0 || z.Sign() < 0 || z.Cmp(q) >= 0 {
+		return fmt.Errorf("proof values out of range")
+	}
+	// the commitments are g^z * y_i^-e and C1^z * D^-e
+	negE := new(big.Int).Neg(e)
+	a1 := group.Mul(group.Exp(group.Generator(), z), group.Exp(verificationKey, negE))
+	a2 := group.Mul(group.Exp(c.C1, z), group.Exp(pd.D, negE))
+	if dleqChallenge(group, pd.Index, verificationKey, c.C1, pd.D, a1, a2).Cmp(e) != 0 {
+		return fmt.Errorf("invalid partial decryption proof of participant %d", pd.Index)
+	}
+	return nil
+}
+
+// CombinePartialDecryptions verifies the decryption shares provided against
+// the verification keys of every participant (verificationKeys[i-1] for the
+// participant i) and combines threshold valid ones to return g^m,
+// C2 / prod(D_i^lambda_i), where lambda_i are the Lagrange coefficients of
+// the participants at 0. Use DiscreteLog to recover m. Repeated shares of a
+// participant are skipped and the ones with an invalid proof dropped, an
+// index out of range is an error. With more than threshold valid shares,
+// g^m is cross-checked with another subset, disjoint from the first one if
+// there are at least 2*threshold of them.
+func CombinePartialDecryptions(params Params, verificationKeys []*big.Int, c *Ciphertext,
+	partials []*PartialDecryption,
+) (*big.Int, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if len(verificationKeys) != params.Participants {
+		return nil, fmt.Errorf("expected %d verification keys, got %d", params.Participants, len(verificationKeys))
+	}
+	if len(partials) < params.Threshold {
+		return nil, fmt.Errorf("needed %d partial decryptions, got %d", params.Threshold, len(partials))
+	}
+	group := params.Group
+	var valid []*PartialDecryption
+	var invalid []int
+	seen := make(map[int]bool, len(partials))
+	for i, pd := range partials {
+		if pd == nil || pd.Index < 1 || pd.Index > params.Participants {
+			return nil, fmt.Errorf("invalid index of partial decryption %d", i)
+		}
+		if seen[pd.Index] {
+			continue
+		}
+		seen[pd.Index] = true
+		if err := VerifyPartialDecryption(group, verificationKeys[pd.Index-1], c, pd); err != nil {
+			invalid = append(invalid, pd.Index)
+			continue
+		}
+		valid = append(valid, pd)
+	}
+	t := params.Threshold
+	if len(valid) < t {
+		return nil, fmt.Errorf("needed %d valid partial decryptions, got %d (invalid partial decryptions of participants %v)",
+			t, len(valid), invalid)
+	}
+	gm, err := combineDecryptions(group, c, valid[:t])
+	if err != nil {
+		return nil, err
+	}
+	if len(valid) > t {
+		check, err := combineDecryptions(group, c, valid[len(valid)-t:])
+		if err != nil {
+			return nil, err
+		}
+		if check.Cmp(gm) != 0 {
+			return nil, fmt.Errorf("subsets of partial decryptions decrypt to different messages")
+		}
+	}
+	return gm, nil
+}
+
+// combineDecryptions returns C2 / prod(D_i^lambda_i) for the partial
+// decryptions provided, which must have distinct indices.
+func combineDecryptions(group Group, c *Ciphertext, partials []*PartialDecryption) (*big.Int, error) {
+	indices := make([]int, len(partials))
+	for i, pd := range partials {
+		indices[i] = pd.Index
+	}
+	// C1^x = prod(D_i^lambda_i)
+	c1x := group.Identity()
+	for _, pd := range partials {
+		lambda, err := LagrangeCoefficient(pd.Index, indices, group.Order())
+		if err != nil {
+			return nil, err
+		}
+		c1x = group.Mul(c1x, group.Exp(pd.D, lambda))
+	}
+	return group.Mul(c.C2, group.Inverse(c1x)), nil
+}
+
+// maxDiscreteLog is the largest bound of DiscreteLog, its table of baby steps
+// has 2^24 entries of two uint64, which take about 512 MiB with the overhead
+// of the map whatever the size of the group elements.
+const maxDiscreteLog = 1 << 48
+
+// DiscreteLog returns m in [0, maxValue] such that g^m = h, with the
+// baby-step giant-step algorithm, which takes O(sqrt(maxValue)) time and
+// memory: about 32 bytes per baby step, 512 MiB for maxDiscreteLog and 32 MiB
+// for 2^40.
+func DiscreteLog(group Group, h *big.Int, maxValue uint64) (uint64, error) {
+	if group == nil {
+		return 0, fmt.Errorf("missing group")
+	}
+	if !group.IsElement(h) {
+		return 0, fmt.Errorf("value is not a group element")
+	}
+	if maxValue > maxDiscreteLog {
+		return 0, fmt.Errorf("max value must be at most %d", uint64(maxDiscreteLog))
+	}
+	// m = ceil(sqrt(maxValue + 1))
+	m := uint64(math.Sqrt(float64(maxValue + 1)))
+	for m*m < maxValue+1 {
+		m++
+	}
+	// baby steps: g^j for j in [0, m), keyed by a digest of the element so
+	// the table does not grow with the size of the group
+	g := group.Generator()
+	babySteps := make(map[uint64]uint64, m)
+	x := group.Identity()
+	for j := uint64(0); j < m; j++ {
+		key := elementDigest(x)
+		if _, ok := babySteps[key]; !ok {
+			babySteps[key] = j
+		}
+		x = group.Mul(x, g)
+	}
+	// giant steps: h * g^(-i*m) for i in [0, m), a match of the digests is
+	// checked to discard collisions
+	factor := group.Inverse(group.Exp(g, new(big.Int).SetUint64(m)))
+	y := new(big.Int).Set(h)
+	for i := uint64(0); i < m; i++ {
+		if j, ok := babySteps[elementDigest(y)]; ok && i*m+j <= maxValue {
+			candidate := i*m + j
+			if group.Exp(g, new(big.Int).SetUint64(candidate)).Cmp(h) == 0 {
+				return candidate, nil
+			}
+		}
+		y = group.Mul(y, factor)
+	}
+	return 0, fmt.Errorf("discrete logarithm not found in [0, %d]", maxValue)
+}
+
+// elementDigest returns the first 8 bytes of SHA-256 of the group element.
+func elementDigest(x *big.Int) uint64 {
+	digest := sha256.Sum256(x.Bytes())
+	return binary.BigEndian.Uint64(digest[:8])
+}
+
+// checkCiphertext checks that both components of the ciphertext are group
+// elements.
+func checkCiphertext(group Group, c *Ciphertext) error {
+	if c == nil || !group.IsElement(c.C1) || !group.IsElement(c.C2) {
+		return fmt.Errorf("ciphertext components must be group elements")
+	}
+	return nil
+}
+
+// dleqChallenge returns the Fiat-Shamir challenge of a Chaum-Pedersen proof,
+// SHA-256 of the group name, the index of the participant, and the
+// generator and the elements provided length prefixed, mod q.
+func dleqChallenge(group Group, index int, elements ...*big.Int) *big.Int {
+	h := sha256.New()
+	h.Write([]byte(group.Name()))
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(index)))
+	for _, x := range append([]*big.Int{group.Generator()}, elements...) {
+		b := x.Bytes()
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(b))))
+		h.Write(b)
+	}
+	e := new(big.Int).SetBytes(h.Sum(nil))
+	return e.Mod(e, group.Order())
+}
@@ -0,0 +1,131 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestThresholdElGamal(t *testing.T) {
+	for _, group := range []Group{BabyJubJub, FFDHE2048} {
+		t.Run(group.Name(), func(t *testing.T) {
+			params := testParams(group, 5, 3)
+			keyShares := runDKG(t, newParticipants(t, params),
+				func(_, _ int, share *big.Int) *big.Int { return share },
+				func(j Justification) *Justification { return &j })
+			publicKey := keyShares[0].PublicKey
+			verificationKeys := keyShares[0].VerificationKeys
+			// encrypt some votes and add them
+			votes := []int64{1, 0, 3, 7, 2}
+			ciphertexts := make([]*Ciphertext, len(votes))
+			expected := uint64(0)
+			for i, vote := range votes {
+				var err error
+				if ciphertexts[i], _, err = Encrypt(rand.Reader, group, publicKey, big.NewInt(vote)); err != nil {
+					t.Fatalf("Error encrypting: %v\n", err)
+				}
+				expected += uint64(vote)
+			}
+			sum, err := AddCiphertexts(group, ciphertexts...)
+			if err != nil {
+				t.Fatalf("Error adding ciphertexts: %v\n", err)
+			}
+			// every subset of threshold participants decrypts the sum
+			for _, subset := range [][]int{{1, 2, 3}, {2, 4, 5}, {5, 1, 3}} {
+				partials := make([]*PartialDecryption, len(subset))
+				for i, idx := range subset {
+					if partials[i], err = keyShares[idx-1].PartialDecrypt(rand.Reader, group, sum); err != nil {
+						t.Fatalf("Error decrypting partially: %v\n", err)
+					}
+				}
+				gm, err := CombinePartialDecryptions(params, verificationKeys, sum, partials)
+				if err != nil {
+					t.Fatalf("Error combining partial decryptions: %v\n", err)
+				}
+				m, err := DiscreteLog(group, gm, 100)
+				if err != nil {
+					t.Fatalf("Error recovering the message: %v\n", err)
+				}
+				if m != expected {
+					t.Errorf("Expected %d with participants %v, got %d\n", expected, subset, m)
+				}
+			}
+			// a partial decryption with a wrong share is rejected
+			pd, err := keyShares[0].PartialDecrypt(rand.Reader, group, sum)
+			if err != nil {
+				t.Fatalf("Error decrypting partially: %v\n", err)
+			}
+			pd.D = group.Mul(pd.D, group.Generator())
+			if err := VerifyPartialDecryption(group, verificationKeys[0], sum, pd); err == nil {
+				t.Error("Expected error verifying a tampered partial decryption")
+			}
+			partials := []*PartialDecryption{pd, pd, pd}
+			if _, err := CombinePartialDecryptions(params, verificationKeys, sum, partials); err == nil {
+				t.Error("Expected error combining invalid partial decryptions")
+			}
+			// the invalid and repeated partial decryptions are dropped, and the
+			// rest cross-checked
+			for _, idx := range []int{3, 2, 3, 5, 4} {
+				valid, err := keyShares[idx-1].PartialDecrypt(rand.Reader, group, sum)
+				if err != nil {
+					t.Fatalf("Error decrypting partially: %v\n", err)
+				}
+				partials = append(partials, valid)
+			}
+			gm, err := CombinePartialDecryptions(params, verificationKeys, sum, partials)
+			if err != nil {
+				t.Fatalf("Error combining partial decryptions: %v\n", err)
+			}
+			if m, err := DiscreteLog(group, gm, 100); err != nil || m != expected {
+				t.Errorf("Expected %d with invalid partial decryptions, got %d (%v)\n", expected, m, err)
+			}
+			partials[len(partials)-1] = &PartialDecryption{Index: 6, D: pd.D, Proof: pd.Proof}
+			if _, err := CombinePartialDecryptions(params, verificationKeys, sum, partials); err == nil {
+				t.Error("Expected error combining a partial decryption with an index out of range")
+			}
+			if _, err := CombinePartialDecryptions(params, verificationKeys, sum, partials[:2]); err == nil {
+				t.Error("Expected error combining too few partial decryptions")
+			}
+		})
+	}
+}
+
+func TestDiscreteLog(t *testing.T) {
+	group := BabyJubJub
+	g := group.Generator()
+	for _, m := range []uint64{0, 1, 99, 100, 12345} {
+		h := group.Exp(g, new(big.Int).SetUint64(m))
+		got, err := DiscreteLog(group, h, 12345)
+		if err != nil {
+			t.Fatalf("Error computing discrete log of %d: %v\n", m, err)
+		}
+		if got != m {
+			t.Errorf("Expected %d, got %d\n", m, got)
+		}
+	}
+	if _, err := DiscreteLog(group, group.Exp(g, big.NewInt(101)), 100); err == nil {
+		t.Error("Expected error computing a discrete log out of bounds")
+	}
+	if _, err := DiscreteLog(group, g, 1<<62); err == nil {
+		t.Error("Expected error with a bound too large")
+	}
+}
+
+func TestLagrangeCoefficient(t *testing.T) {
+	q := big.NewInt(101)
+	// f(x) = 5 + 3x, f(1) = 8, f(3) = 14
+	sum := big.NewInt(0)
+	for idx, share := range map[int]int64{1: 8, 3: 14} {
+		lambda, err := LagrangeCoefficient(idx, []int{1, 3}, q)
+		if err != nil {
+			t.Fatalf("Error computing coefficient: %v\n", err)
+		}
+		sum.Add(sum, lambda.Mul(lambda, big.NewInt(share))).Mod(sum, q)
+	}
+	if sum.Int64() != 5 {
+		t.Errorf("Expected 5, got %v\n", sum)
+	}
+	if _, err := LagrangeCoefficient(1, []int{1, 102}, q); err == nil {
+		t.Error("Expected error with indices equal mod q")
+	}
+}
@@ -0,0 +1,396 @@
+package dkg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+)
+
+// Group is a cyclic group of prime order where the discrete logarithm is
+// hard, in which the DKG runs. Its elements are encoded as big integers: the
+// residues mod p of a multiplicative group, or the compressed points of an
+// elliptic curve, so commitments and public keys have the same type over any
+// group. The scalars are the integers mod the order of the group. The
+// operations expect valid elements, checked with IsElement, and panic
+// otherwise when the elements can not be decoded.
+type Group interface {
+	// Name returns the name of the group.
+	Name() string
+	// Order returns q, the prime order of the group.
+	Order() *big.Int
+	// Generator returns the generator g of the group.
+	Generator() *big.Int
+	// Identity returns the identity element of the group.
+	Identity() *big.Int
+	// Mul returns the group operation of x and y, x*y.
+	Mul(x, y *big.Int) *big.Int
+	// Exp returns x^k, where k is reduced mod q.
+	Exp(x, k *big.Int) *big.Int
+	// Inverse returns x^-1.
+	Inverse(x *big.Int) *big.Int
+	// IsElement checks that x is the canonical encoding of an element of the
+	// group.
+	IsElement(x *big.Int) bool
+}
+
+// MODPGroup is the subgroup of order q of Z_p^*, where p = 2q + 1 is a safe
+// prime, generated by g.
+type MODPGroup struct {
+	name    string
+	p, q, g *big.Int
+}
+
+// predefined groups, their parameters are validated by the tests
+var (
+	// RFC3526Group2048, RFC3526Group3072 and RFC3526Group4096 are the MODP
+	// groups 14, 15 and 16 of RFC 3526, with g = 2.
+	RFC3526Group2048 = newMODPGroup("rfc3526-2048", rfc3526Prime2048)
+	RFC3526Group3072 = newMODPGroup("rfc3526-3072", rfc3526Prime3072)
+	RFC3526Group4096 = newMODPGroup("rfc3526-4096", rfc3526Prime4096)
+	// FFDHE2048, FFDHE3072 and FFDHE4096 are the finite field groups of RFC
+	// 7919, with g = 2.
+	FFDHE2048 = newMODPGroup("ffdhe2048", ffdhe2048Prime)
+	FFDHE3072 = newMODPGroup("ffdhe3072", ffdhe3072Prime)
+	FFDHE4096 = newMODPGroup("ffdhe4096", ffdhe4096Prime)
+	// BabyJubJub is the subgroup of prime order of the BabyJubJub curve,
+	// generated by B8.
+	BabyJubJub Group = babyJubJubGroup{}
+)
+
+// groups are the predefined groups, indexed by name.
+var groups = map[string]Group{
+	RFC3526Group2048.Name(): RFC3526Group2048,
+	RFC3526Group3072.Name(): RFC3526Group3072,
+	RFC3526Group4096.Name(): RFC3526Group4096,
+	FFDHE2048.Name():        FFDHE2048,
+	FFDHE3072.Name():        FFDHE3072,
+	FFDHE4096.Name():        FFDHE4096,
+	BabyJubJub.Name():       BabyJubJub,
+}
+
+// GroupByName returns the predefined group with the name provided, so the
+// participants can agree on a group by its name.
+func GroupByName(name string) (Group, error) {
+	group, ok := groups[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown group %q", name)
+	}
+	return group, nil
+}
+
+// GroupNames returns the sorted names of the predefined groups.
+func GroupNames() []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// RandomScalar returns a random non-zero scalar of the group, in [1, q), read
+// from the randomness source provided.
+func RandomScalar(random io.Reader, group Group) (*big.Int, error) {
+	if group == nil {
+		return nil, fmt.Errorf("missing group")
+	}
+	k, err := randomInt(random, new(big.Int).Sub(group.Order(), big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+// NewMODPGroup returns the group generated by g in Z_p^*, checking that p is a
+// safe prime and that g generates its subgroup of order q = (p-1)/2, for
+// example with the values of GenerateSafePrime and FindGenerator. Its name is
+// modp-<bits>-<digest>, with the first 8 bytes of SHA-256 of p and g, so
+// custom groups of the same size have different names.
+func NewMODPGroup(p, g *big.Int) (*MODPGroup, error) {
+	if p == nil || g == nil {
+		return nil, fmt.Errorf("missing group parameters")
+	}
+	q := new(big.Int).Rsh(p, 1)
+	if p.Bit(0) == 0 || !p.ProbablyPrime(20) || !q.ProbablyPrime(20) {
+		return nil, fmt.Errorf("p is not a safe prime")
+	}
+	group := &MODPGroup{
+		name: fmt.Sprintf("modp-%d-%x", p.BitLen(), modpDigest(p, g)),
+		p:    new(big.Int).Set(p),
+		q:    q,
+		g:    new(big.Int).Set(g),
+	}
+	if g.Cmp(big.NewInt(1)) <= 0 || !group.IsElement(g) {
+		return nil, fmt.Errorf("g does not generate the subgroup of order q")
+	}
+	return group, nil
+}
+
+// modpDigest returns the first 8 bytes of SHA-256 of p and g, length
+// prefixed.
+func modpDigest(p, g *big.Int) []byte {
+	h := sha256.New()
+	for _, x := range []*big.Int{p, g} {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(x.Bytes()))))
+		h.Write(x.Bytes())
+	}
+	return h.Sum(nil)[:8]
+}
+
+// sameGroup checks that both groups are the same: MODP groups with the same p
+// and g, whatever their names, or other groups with the same name, order and
+// generator.
+func sameGroup(a, b Group) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ma, okA := a.(*MODPGroup)
+	mb, okB := b.(*MODPGroup)
+	if okA || okB {
+		return okA && okB && ma.p.Cmp(mb.p) == 0 && ma.g.Cmp(mb.g) == 0
+	}
+	return a.Name() == b.Name() && a.Order().Cmp(b.Order()) == 0 && a.Generator().Cmp(b.Generator()) == 0
+}
+
+// newMODPGroup returns the group of the hexadecimal safe prime provided with
+// g = 2, which must be a quadratic residue mod p.
+func newMODPGroup(name, hexPrime string) *MODPGroup {
+	p, ok := new(big.Int).SetString(hexPrime, 16)
+	if !ok {
+		panic(fmt.Sprintf("invalid prime of group %s", name))
+	}
+	return &MODPGroup{
+		name: name,
+		p:    p,
+		q:    new(big.Int).Rsh(p, 1),
+		g:    big.NewInt(2),
+	}
+}
+
+// Name returns the name of the group.
+func (m *MODPGroup) Name() string {
+	return m.name
+}
+
+// Modulus returns the safe prime p.
+func (m *MODPGroup) Modulus() *big.Int {
+	return new(big.Int).Set(m.p)
+}
+
+// Order returns q = (p-1)/2.
+func (m *MODPGroup) Order() *big.Int {
+	return new(big.Int).Set(m.q)
+}
+
+// Generator returns g.
+func (m *MODPGroup) Generator() *big.Int {
+	return new(big.Int).Set(m.g)
+}
+
+// Identity returns 1.
+func (m *MODPGroup) Identity() *big.Int {
+	return big.NewInt(1)
+}
+
+// Mul returns x*y mod p.
+func (m *MODPGroup) Mul(x, y *big.Int) *big.Int {
+	z := new(big.Int).Mul(x, y)
+	return z.Mod(z, m.p)
+}
+
+// Exp returns x^(k mod q) mod p.
+func (m *MODPGroup) Exp(x, k *big.Int) *big.Int {
+	return new(big.Int).Exp(x, new(big.Int).Mod(k, m.q), m.p)
+}
+
+// Inverse returns x^-1 mod p.
+func (m *MODPGroup) Inverse(x *big.Int) *big.Int {
+	return new(big.Int).ModInverse(x, m.p)
+}
+
+// IsElement checks that x is in [1, p) and x^q = 1 mod p.
+func (m *MODPGroup) IsElement(x *big.Int) bool {
+	if x == nil || x.Sign() <= 0 || x.Cmp(m.p) >= 0 {
+		return false
+	}
+	return new(big.Int).Exp(x, m.q, m.p).Cmp(big.NewInt(1)) == 0
+}
+
+// babyJubJubGroup is the subgroup of prime order of the BabyJubJub curve. A
+// point is encoded as the integer of its compressed form: the y coordinate
+// with the sign of the x coordinate in the bit 255, so the identity (0, 1) is
+// encoded as 1.
+type babyJubJubGroup struct{}
+
+// Name returns "babyjubjub".
+func (babyJubJubGroup) Name() string {
+	return "babyjubjub"
+}
+
+// Order returns the order of the subgroup of the curve.
+func (babyJubJubGroup) Order() *big.Int {
+	return new(big.Int).Set(babyjub.SubOrder)
+}
+
+// Generator returns the encoding of B8.
+func (babyJubJubGroup) Generator() *big.Int {
+	return encodePoint(babyjub.B8)
+}
+
+// Identity returns the encoding of (0, 1).
+func (babyJubJubGroup) Identity() *big.Int {
+	return encodePoint(babyjub.NewPoint())
+}
+
+// Mul returns the encoding of the sum of the points x and y.
+func (babyJubJubGroup) Mul(x, y *big.Int) *big.Int {
+	a, b := mustDecodePoint(x).Projective(), mustDecodePoint(y).Projective()
+	return encodePoint(a.Add(a, b).Affine())
+}
+
+// Exp returns the encoding of the point x multiplied by k mod the order.
+func (babyJubJubGroup) Exp(x, k *big.Int) *big.Int {
+	return encodePoint(babyjub.NewPoint().Mul(new(big.Int).Mod(k, babyjub.SubOrder), mustDecodePoint(x)))
+}
+
+// Inverse returns the encoding of the opposite of the point x, (-x, y).
+func (babyJubJubGroup) Inverse(x *big.Int) *big.Int {
+	p := mustDecodePoint(x)
+	p.X.Neg(p.X).Mod(p.X, constants.Q)
+	return encodePoint(p)
+}
+
+// IsElement checks that x is the canonical encoding of a point of the
+// subgroup of the curve.
+func (babyJubJubGroup) IsElement(x *big.Int) bool {
+	p, err := decodePoint(x)
+	return err == nil && p.InSubGroup() && encodePoint(p).Cmp(x) == 0
+}
+
+// encodePoint returns the integer of the compressed point provided, which is
+// little-endian.
+func encodePoint(p *babyjub.Point) *big.Int {
+	compressed := p.Compress()
+	slices.Reverse(compressed[:])
+	return new(big.Int).SetBytes(compressed[:])
+}
+
+// decodePoint decompresses the point encoded by x.
+func decodePoint(x *big.Int) (*babyjub.Point, error) {
+	if x == nil || x.Sign() < 0 || x.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid point encoding")
+	}
+	var compressed [32]byte
+	x.FillBytes(compressed[:])
+	slices.Reverse(compressed[:])
+	return new(babyjub.Point).Decompress(compressed)
+}
+
+// mustDecodePoint decompresses the point encoded by x, panicking if it is
+// not a point of the curve.
+func mustDecodePoint(x *big.Int) *babyjub.Point {
+	p, err := decodePoint(x)
+	if err != nil {
+		panic(fmt.Sprintf("invalid babyjubjub point: %v", err))
+	}
+	return p
+}
+
+// prime moduli of the predefined MODP groups, in hexadecimal
+const (
+	// rfc3526Prime2048 is the prime of the RFC 3526 2048-bit MODP group (group 14).
+	rfc3526Prime2048 = "" +
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF"
+
+	// rfc3526Prime3072 is the prime of the RFC 3526 3072-bit MODP group (group 15).
+	rfc3526Prime3072 = "" +
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33" +
+		"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864" +
+		"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2" +
+		"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF"
+
+	// rfc3526Prime4096 is the prime of the RFC 3526 4096-bit MODP group (group 16).
+	rfc3526Prime4096 = "" +
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33" +
+		"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864" +
+		"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2" +
+		"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A92108011A723C12A787E6D7" +
+		"88719A10BDBA5B2699C327186AF4E23C1A946834B6150BDA2583E9CA2AD44CE8" +
+		"DBBBC2DB04DE8EF92E8EFC141FBECAA6287C59474E6BC05D99B2964FA090C3A2" +
+		"233BA186515BE7ED1F612970CEE2D7AFB81BDD762170481CD0069127D5B05AA9" +
+		"93B4EA988D8FDDC186FFB7DC90A6C08F4DF435C934063199FFFFFFFFFFFFFFFF"
+
+	// ffdhe2048Prime is the prime of the RFC 7919 ffdhe2048 group.
+	ffdhe2048Prime = "" +
+		"FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695" +
+		"A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617A" +
+		"D3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935" +
+		"984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797A" +
+		"BC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4" +
+		"AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F61" +
+		"9172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005" +
+		"C58EF1837D1683B2C6F34A26C1B2EFFA886B423861285C97FFFFFFFFFFFFFFFF"
+
+	// ffdhe3072Prime is the prime of the RFC 7919 ffdhe3072 group.
+	ffdhe3072Prime = "" +
+		"FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695" +
+		"A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617A" +
+		"D3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935" +
+		"984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797A" +
+		"BC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4" +
+		"AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F61" +
+		"9172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005" +
+		"C58EF1837D1683B2C6F34A26C1B2EFFA886B4238611FCFDCDE355B3B6519035B" +
+		"BC34F4DEF99C023861B46FC9D6E6C9077AD91D2691F7F7EE598CB0FAC186D91C" +
+		"AEFE130985139270B4130C93BC437944F4FD4452E2D74DD364F2E21E71F54BFF" +
+		"5CAE82AB9C9DF69EE86D2BC522363A0DABC521979B0DEADA1DBF9A42D5C4484E" +
+		"0ABCD06BFA53DDEF3C1B20EE3FD59D7C25E41D2B66C62E37FFFFFFFFFFFFFFFF"
+
+	// ffdhe4096Prime is the prime of the RFC 7919 ffdhe4096 group.
+	ffdhe4096Prime = "" +
+		"FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695" +
+		"A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617A" +
+		"D3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935" +
+		"984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797A" +
+		"BC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4" +
+		"AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F61" +
+		"9172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005" +
+		"C58EF1837D1683B2C6F34A26C1B2EFFA886B4238611FCFDCDE355B3B6519035B" +
+		"BC34F4DEF99C023861B46FC9D6E6C9077AD91D2691F7F7EE598CB0FAC186D91C" +
+		"AEFE130985139270B4130C93BC437944F4FD4452E2D74DD364F2E21E71F54BFF" +
+		"5CAE82AB9C9DF69EE86D2BC522363A0DABC521979B0DEADA1DBF9A42D5C4484E" +
+		"0ABCD06BFA53DDEF3C1B20EE3FD59D7C25E41D2B669E1EF16E6F52C3164DF4FB" +
+		"7930E9E4E58857B6AC7D5F42D69F6D187763CF1D5503400487F55BA57E31CC7A" +
+		"7135C886EFB4318AED6A1E012D9E6832A907600A918130C46DC778F971AD0038" +
+		"092999A333CB8B7A1A1DB93D7140003C2A4ECEA9F98D0ACC0A8291CDCEC97DCF" +
+		"8EC9B55A7F88A46B4DB5A851F44182E1C68A007E5E655F6AFFFFFFFFFFFFFFFF"
+)
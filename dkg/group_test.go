@@ -0,0 +1,84 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPredefinedGroups(t *testing.T) {
+	for _, group := range []*MODPGroup{
+		RFC3526Group2048, RFC3526Group3072, RFC3526Group4096,
+		FFDHE2048, FFDHE3072, FFDHE4096,
+	} {
+		if testing.Short() && group.Modulus().BitLen() > 2048 {
+			continue
+		}
+		// the predefined parameters must pass the validation of custom groups
+		if _, err := NewMODPGroup(group.Modulus(), group.Generator()); err != nil {
+			t.Errorf("Invalid group %s: %v\n", group.Name(), err)
+		}
+	}
+	for _, name := range GroupNames() {
+		group, err := GroupByName(name)
+		if err != nil {
+			t.Fatalf("Error getting group %s: %v\n", name, err)
+		}
+		if group.Name() != name {
+			t.Errorf("Expected group %s, got %s\n", name, group.Name())
+		}
+	}
+	if _, err := GroupByName("modp-1024"); err == nil {
+		t.Error("Expected error getting an unknown group")
+	}
+}
+
+func TestGroupOperations(t *testing.T) {
+	for _, group := range []Group{BabyJubJub, FFDHE2048} {
+		t.Run(group.Name(), func(t *testing.T) {
+			g, q := group.Generator(), group.Order()
+			if !group.IsElement(g) || !group.IsElement(group.Identity()) {
+				t.Fatal("Expected the generator and the identity to be elements")
+			}
+			if group.Exp(g, q).Cmp(group.Identity()) != 0 {
+				t.Error("Expected g^q to be the identity")
+			}
+			a, err := RandomScalar(rand.Reader, group)
+			if err != nil {
+				t.Fatalf("Error generating scalar: %v\n", err)
+			}
+			b, err := RandomScalar(rand.Reader, group)
+			if err != nil {
+				t.Fatalf("Error generating scalar: %v\n", err)
+			}
+			ga, gb := group.Exp(g, a), group.Exp(g, b)
+			if !group.IsElement(ga) {
+				t.Error("Expected g^a to be an element")
+			}
+			// g^a * g^b = g^(a+b)
+			if group.Mul(ga, gb).Cmp(group.Exp(g, new(big.Int).Add(a, b))) != 0 {
+				t.Error("Expected g^a * g^b to be g^(a+b)")
+			}
+			// (g^a)^b = (g^b)^a
+			if group.Exp(ga, b).Cmp(group.Exp(gb, a)) != 0 {
+				t.Error("Expected (g^a)^b to be (g^b)^a")
+			}
+			// g^a * g^-a = 1, and negative exponents are reduced mod q
+			if group.Mul(ga, group.Inverse(ga)).Cmp(group.Identity()) != 0 {
+				t.Error("Expected g^a * (g^a)^-1 to be the identity")
+			}
+			if group.Exp(g, new(big.Int).Neg(a)).Cmp(group.Inverse(ga)) != 0 {
+				t.Error("Expected g^-a to be (g^a)^-1")
+			}
+			for _, x := range []*big.Int{nil, big.NewInt(0), big.NewInt(-1), new(big.Int).Lsh(big.NewInt(1), 4096)} {
+				if group.IsElement(x) {
+					t.Errorf("Unexpected element %v\n", x)
+				}
+			}
+		})
+	}
+	// 2 does not encode a point of the subgroup
+	if BabyJubJub.IsElement(big.NewInt(2)) {
+		t.Error("Unexpected element of the babyjubjub subgroup")
+	}
+}
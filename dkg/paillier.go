@@ -0,0 +1,622 @@
+package dkg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/paillier"
+)
+
+const (
+	// statSecurity is the statistical security parameter, in bits, of the
+	// masks used to hide the shares in the multiplications and resharing.
+	statSecurity = 80
+	// biprimalityRounds is the number of rounds of the biprimality test, a
+	// modulus that is not the product of two primes passes every round with
+	// probability at most 2^-biprimalityRounds.
+	biprimalityRounds = 40
+	// trialDivisionBound is the bound of the small primes used to discard
+	// candidate moduli before the biprimality test.
+	trialDivisionBound = 10000
+)
+
+// smallPrimes are the odd primes below trialDivisionBound.
+var smallPrimes = func() []int64 {
+	sieve := make([]bool, trialDivisionBound)
+	primes := []int64{}
+	for i := 3; i < trialDivisionBound; i += 2 {
+		if sieve[i] {
+			continue
+		}
+		primes = append(primes, int64(i))
+		for j := i * i; j < trialDivisionBound; j += 2 * i {
+			sieve[j] = true
+		}
+	}
+	return primes
+}()
+
+// PaillierParams holds the public parameters of a distributed Paillier key
+// generation: the bit size of the modulus n, which must be even, the number
+// of participants and the threshold, that is the number of decryption shares
+// required to decrypt.
+type PaillierParams struct {
+	BitSize      int
+	Participants int
+	Threshold    int
+}
+
+// validate checks that the parameters are consistent and can be represented
+// in a tcpaillier key.
+func (p PaillierParams) validate() error {
+	if p.BitSize < 64 || p.BitSize%2 != 0 {
+		return fmt.Errorf("bit size must be an even number of at least 64 bits, got %d", p.BitSize)
+	}
+	if p.Participants < 2 || p.Participants > 255 {
+		return fmt.Errorf("participants must be between 2 and 255, got %d", p.Participants)
+	}
+	if p.Threshold < p.Participants/2+1 || p.Threshold > p.Participants {
+		return fmt.Errorf("threshold must be between %d and %d, got %d",
+			p.Participants/2+1, p.Participants, p.Threshold)
+	}
+	return nil
+}
+
+// shareBits returns the number of random bits of the additive share of every
+// participant of a prime candidate, small enough for the sum of the shares to
+// stay below 2^(BitSize/2-2).
+func (p PaillierParams) shareBits() int {
+	return p.BitSize/2 - 2 - big.NewInt(int64(p.Participants)).BitLen()
+}
+
+// paillier party states, the rounds must be run in this order for every
+// candidate modulus
+const (
+	paillierStateNew = iota
+	paillierStateSampled
+	paillierStateModulus
+	paillierStateExponent
+	paillierStateMultiplied
+	paillierStateReshared
+	paillierStateFinalized
+)
+
+// PaillierParty runs the rounds of a dealerless threshold Paillier key
+// generation for a single participant, so nobody ever knows the
+// factorization of n nor the decryption exponent:
+//
+//  1. SampleCandidate: additive shares p_i and q_i of two candidate primes,
+//     p = sum(p_i) and q = sum(q_i), both 3 mod 4.
+//  2. MultiplyCandidate and ModulusShare: additive shares of n = pq from the
+//     cross products p_i*q_j, computed with a multiplicative-to-additive
+//     (MtA) conversion under the Paillier auxiliary key of the participant i.
+//     The sum of the modulus shares is checked with CheckModulus (trial
+//     division) and CheckBiprimality, with the BiprimalityShares of every
+//     participant. If n is rejected, the parties sample a new candidate.
+//  3. SampleExponent, MultiplyExponent and ExponentShare: additive integer
+//     shares of phi*beta, where phi = (p-1)(q-1) and beta = sum(beta_i) is
+//     random, and theta = phi*beta mod n is revealed.
+//  4. Reshare: the decryption exponent d = phi*beta*(theta^-1 mod n), which
+//     is 0 mod phi and 1 mod n, is reshared with a Shamir sharing over the
+//     integers. The shares are not scaled, tcpaillier multiplies the Lagrange
+//     coefficients by delta = l! to make them integers when combining.
+//  5. Finalize: the share of every participant and its verification key.
+//
+// The messages of every round must be broadcast, except the MtA responses
+// and the reshared shares, which must be sent privately to their recipient.
+// It assumes honest-but-curious participants, the messages are not proven
+// correct. It is not safe for concurrent use.
+type PaillierParty struct {
+	params PaillierParams
+	index  int
+	random io.Reader
+	state  int
+	aux    *paillier.PrivateKey
+	// candidate shares and the results of the multiplications
+	p, q, n   *big.Int
+	phi, beta *big.Int
+	left      *big.Int
+	right     *big.Int
+	mtaShare  *big.Int
+	product   *big.Int
+	// share of the decryption exponent
+	si *big.Int
+}
+
+// NewPaillierParty creates the participant with the index provided, between
+// 1 and params.Participants, and generates its auxiliary Paillier key for the
+// multiplications, large enough to hold a masked product of two shares. The
+// shares, masks and coefficients of every round are read from the randomness
+// source provided, the auxiliary key and its encryptions use crypto/rand.
+func NewPaillierParty(random io.Reader, params PaillierParams, index int) (*PaillierParty, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if index < 1 || index > params.Participants {
+		return nil, fmt.Errorf("index must be between 1 and %d, got %d", params.Participants, index)
+	}
+	if random == nil {
+		return nil, fmt.Errorf("missing randomness source")
+	}
+	aux, err := paillier.GenerateKey(2*params.BitSize+statSecurity+8, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error generating auxiliary key: %w", err)
+	}
+	return &PaillierParty{
+		params: params,
+		index:  index,
+		random: random,
+		aux:    aux,
+	}, nil
+}
+
+// Index returns the index of the participant.
+func (pp *PaillierParty) Index() int {
+	return pp.index
+}
+
+// AuxKey returns the auxiliary Paillier public key of the participant, that
+// the other participants use to answer its multiplications.
+func (pp *PaillierParty) AuxKey() *paillier.PublicKey {
+	return &pp.aux.PublicKey
+}
+
+// SampleCandidate discards the current candidate, if any, samples the shares
+// of a new candidate and returns the share p_i encrypted with the auxiliary
+// key of the participant. The participant 1 shares are 3 mod 4 and include
+// an offset that sets the two most significant bits of p and q, so n has
+// exactly BitSize bits, the shares of the rest of participants are 0 mod 4.
+func (pp *PaillierParty) SampleCandidate() (*big.Int, error) {
+	if pp.state > paillierStateModulus {
+		return nil, fmt.Errorf("participant %d already accepted a modulus", pp.index)
+	}
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(pp.params.shareBits()))
+	offset := new(big.Int).Lsh(big.NewInt(3), uint(pp.params.BitSize/2-2))
+	shares := make([]*big.Int, 2)
+	for i := range shares {
+		share, err := randomInt(pp.random, bound)
+		if err != nil {
+			return nil, err
+		}
+		// clear the two least significant bits
+		share.Rsh(share, 2).Lsh(share, 2)
+		if pp.index == 1 {
+			share.Add(share, offset).Add(share, big.NewInt(3))
+		}
+		shares[i] = share
+	}
+	pp.p, pp.q, pp.n = shares[0], shares[1], nil
+	pp.state = paillierStateSampled
+	return pp.startMtA(pp.p, pp.q)
+}
+
+// MultiplyCandidate answers the encrypted shares p_j of every other
+// participant, indexed by participant, with an encryption of p_j*q_i plus a
+// random mask under the auxiliary key of the participant j. The responses
+// are indexed by their recipient. auxKeys[j-1] is the auxiliary key of the
+// participant j.
+func (pp *PaillierParty) MultiplyCandidate(auxKeys []*paillier.PublicKey, encShares map[int]*big.Int) (map[int]*big.Int, error) {
+	if pp.state != paillierStateSampled {
+		return nil, fmt.Errorf("participant %d must sample a candidate before multiplying", pp.index)
+	}
+	return pp.respondMtA(auxKeys, encShares)
+}
+
+// ModulusShare decrypts the responses of every other participant, indexed by
+// sender, and returns the additive share of the candidate modulus n, which
+// must be broadcast.
+func (pp *PaillierParty) ModulusShare(responses map[int]*big.Int) (*big.Int, error) {
+	if pp.state != paillierStateSampled {
+		return nil, fmt.Errorf("participant %d must sample a candidate before sharing the modulus", pp.index)
+	}
+	share, err := pp.finishMtA(responses)
+	if err != nil {
+		return nil, err
+	}
+	pp.state = paillierStateModulus
+	return share, nil
+}
+
+// BiprimalityShares returns the share of the participant of every round of
+// the biprimality test of the candidate modulus n, one per challenge:
+// g^((n-p_1-q_1+1)/4) for the participant 1 and g^((p_i+q_i)/4) for the
+// rest of participants. The shares must be broadcast.
+func (pp *PaillierParty) BiprimalityShares(n *big.Int, challenges []*big.Int) ([]*big.Int, error) {
+	if pp.state != paillierStateModulus {
+		return nil, fmt.Errorf("participant %d must share the modulus before testing it", pp.index)
+	}
+	exp := new(big.Int).Add(pp.p, pp.q)
+	if pp.index == 1 {
+		exp.Sub(new(big.Int).Add(n, big.NewInt(1)), exp)
+	}
+	exp.Rsh(exp, 2)
+	shares := make([]*big.Int, len(challenges))
+	for i, g := range challenges {
+		shares[i] = new(big.Int).Exp(g, exp, n)
+	}
+	return shares, nil
+}
+
+// SampleExponent accepts n as the modulus, computes the share of phi of the
+// participant, n-p_1-q_1+1 for the participant 1 and -(p_i+q_i) for the
+// rest, samples its share of beta in [0, n) and returns the share of phi
+// encrypted with the auxiliary key of the participant.
+func (pp *PaillierParty) SampleExponent(n *big.Int) (*big.Int, error) {
+	if pp.state != paillierStateModulus {
+		return nil, fmt.Errorf("participant %d must share the modulus before sampling the exponent", pp.index)
+	}
+	if n == nil || n.BitLen() != pp.params.BitSize {
+		return nil, fmt.Errorf("modulus must have %d bits", pp.params.BitSize)
+	}
+	phi := new(big.Int).Add(pp.p, pp.q)
+	phi.Neg(phi)
+	if pp.index == 1 {
+		phi.Add(phi, n).Add(phi, big.NewInt(1))
+	}
+	beta, err := randomInt(pp.random, n)
+	if err != nil {
+		return nil, err
+	}
+	pp.n, pp.phi, pp.beta = new(big.Int).Set(n), phi, beta
+	pp.state = paillierStateExponent
+	return pp.startMtA(pp.phi, pp.beta)
+}
+
+// MultiplyExponent answers the encrypted shares phi_j of every other
+// participant like MultiplyCandidate, with encryptions of phi_j*beta_i plus
+// a random mask.
+func (pp *PaillierParty) MultiplyExponent(auxKeys []*paillier.PublicKey, encShares map[int]*big.Int) (map[int]*big.Int, error) {
+	if pp.state != paillierStateExponent {
+		return nil, fmt.Errorf("participant %d must sample the exponent before multiplying", pp.index)
+	}
+	return pp.respondMtA(auxKeys, encShares)
+}
+
+// ExponentShare decrypts the responses of every other participant, indexed by
+// sender, and returns the share of theta = phi*beta mod n of the participant,
+// which must be broadcast. The integer share of phi*beta is kept.
+func (pp *PaillierParty) ExponentShare(responses map[int]*big.Int) (*big.Int, error) {
+	if pp.state != paillierStateExponent {
+		return nil, fmt.Errorf("participant %d must sample the exponent before sharing it", pp.index)
+	}
+	share, err := pp.finishMtA(responses)
+	if err != nil {
+		return nil, err
+	}
+	pp.product = share
+	pp.state = paillierStateMultiplied
+	return new(big.Int).Mod(share, pp.n), nil
+}
+
+// Reshare computes theta from the shares of every participant and the
+// additive share of the decryption exponent d_i = (phi*beta)_i * theta^-1,
+// and returns the share f_i(j) of every participant j, including its own
+// one, of a random integer polynomial with f_i(0) = d_i. The coefficients
+// are positive and larger than |d_i| times delta^2 = (l!)^2 by statSecurity
+// bits, so every share is positive and the shares of less than threshold
+// participants, and the integer Lagrange factors up to delta between them,
+// hide d_i statistically.
+func (pp *PaillierParty) Reshare(thetaShares []*big.Int) (map[int]*big.Int, error) {
+	if pp.state != paillierStateMultiplied {
+		return nil, fmt.Errorf("participant %d must share the exponent before resharing it", pp.index)
+	}
+	if len(thetaShares) != pp.params.Participants {
+		return nil, fmt.Errorf("expected %d theta shares, got %d", pp.params.Participants, len(thetaShares))
+	}
+	theta := big.NewInt(0)
+	for i, share := range thetaShares {
+		if share == nil || share.Sign() < 0 || share.Cmp(pp.n) >= 0 {
+			return nil, fmt.Errorf("theta share of participant %d out of range", i+1)
+		}
+		theta.Add(theta, share)
+	}
+	theta.Mod(theta, pp.n)
+	thetaInv := new(big.Int).ModInverse(theta, pp.n)
+	if thetaInv == nil {
+		return nil, fmt.Errorf("theta is not invertible mod n")
+	}
+	d := new(big.Int).Mul(pp.product, thetaInv)
+	// the coefficients are in [2^b, 2^(b+1)), with 2^b > 2^statSecurity *
+	// delta^2 * |d_i|
+	delta := new(big.Int).MulRange(1, int64(pp.params.Participants))
+	b := uint(d.BitLen() + statSecurity + 2*delta.BitLen())
+	low := new(big.Int).Lsh(big.NewInt(1), b)
+	coeffs := make([]*big.Int, pp.params.Threshold)
+	coeffs[0] = d
+	for i := 1; i < len(coeffs); i++ {
+		coeff, err := randomInt(pp.random, low)
+		if err != nil {
+			return nil, err
+		}
+		coeffs[i] = coeff.Add(coeff, low)
+	}
+	shares := make(map[int]*big.Int, pp.params.Participants)
+	for j := 1; j <= pp.params.Participants; j++ {
+		// Horner's method over the integers
+		x := big.NewInt(int64(j))
+		share := new(big.Int)
+		for i := len(coeffs) - 1; i >= 0; i-- {
+			share.Mul(share, x).Add(share, coeffs[i])
+		}
+		shares[j] = share
+	}
+	pp.state = paillierStateReshared
+	return shares, nil
+}
+
+// Finalize adds the shares received from every participant, indexed by
+// dealer, and returns the verification key of the participant,
+// V^(delta*s_i) mod n^2, where V is derived from n, which must be broadcast.
+func (pp *PaillierParty) Finalize(shares map[int]*big.Int) (*big.Int, error) {
+	if pp.state != paillierStateReshared {
+		return nil, fmt.Errorf("participant %d must reshare before finalizing", pp.index)
+	}
+	si := big.NewInt(0)
+	for dealer := 1; dealer <= pp.params.Participants; dealer++ {
+		share, ok := shares[dealer]
+		if !ok || share == nil || share.Sign() <= 0 {
+			return nil, fmt.Errorf("missing or invalid share of dealer %d", dealer)
+		}
+		si.Add(si, share)
+	}
+	v, err := verificationBase(pp.n)
+	if err != nil {
+		return nil, err
+	}
+	nSquare := new(big.Int).Mul(pp.n, pp.n)
+	deltaSi := new(big.Int).Mul(new(big.Int).MulRange(1, int64(pp.params.Participants)), si)
+	pp.si = si
+	pp.state = paillierStateFinalized
+	return new(big.Int).Exp(v, deltaSi, nSquare), nil
+}
+
+// KeyShare returns the tcpaillier key share of the participant, with the
+// public key built by NewPaillierPubKey from the verification keys of every
+// participant.
+func (pp *PaillierParty) KeyShare(verificationKeys []*big.Int) (*tcpaillier.KeyShare, error) {
+	if pp.state != paillierStateFinalized {
+		return nil, fmt.Errorf("participant %d must finalize before getting its key share", pp.index)
+	}
+	pk, err := NewPaillierPubKey(pp.params, pp.n, verificationKeys)
+	if err != nil {
+		return nil, err
+	}
+	deltaSi := new(big.Int).Mul(pk.Delta, pp.si)
+	if new(big.Int).Exp(pk.V, deltaSi, pk.Cache().NToSPlusOne).Cmp(pk.Vi[pp.index-1]) != 0 {
+		return nil, fmt.Errorf("verification key of participant %d does not match its share", pp.index)
+	}
+	return &tcpaillier.KeyShare{
+		PubKey: pk,
+		Index:  uint8(pp.index),
+		Si:     new(big.Int).Set(pp.si),
+	}, nil
+}
+
+// startMtA stores the values of the participant for a round of
+// multiplications and returns the left one encrypted with its auxiliary key,
+// negative values are encrypted mod the auxiliary n.
+func (pp *PaillierParty) startMtA(left, right *big.Int) (*big.Int, error) {
+	pp.left, pp.right = left, right
+	pp.mtaShare = new(big.Int).Mul(left, right)
+	c, _, err := pp.aux.Encrypt(new(big.Int).Mod(left, pp.aux.N))
+	return c, err
+}
+
+// respondMtA answers the encrypted left value a_j of every other participant
+// with an encryption of a_j*b_i + gamma_j under its auxiliary key, where b_i
+// is the right value of the participant and gamma_j is a random mask that is
+// subtracted from the share of the participant.
+func (pp *PaillierParty) respondMtA(auxKeys []*paillier.PublicKey, encShares map[int]*big.Int) (map[int]*big.Int, error) {
+	if len(auxKeys) != pp.params.Participants {
+		return nil, fmt.Errorf("expected %d auxiliary keys, got %d", pp.params.Participants, len(auxKeys))
+	}
+	maskBound := new(big.Int).Lsh(big.NewInt(1), uint(2*pp.params.BitSize+statSecurity))
+	responses := make(map[int]*big.Int, pp.params.Participants-1)
+	for j := 1; j <= pp.params.Participants; j++ {
+		if j == pp.index {
+			continue
+		}
+		key, c := auxKeys[j-1], encShares[j]
+		if key == nil || key.N.BitLen() < maskBound.BitLen()+4 {
+			return nil, fmt.Errorf("invalid auxiliary key of participant %d", j)
+		}
+		if c == nil || c.Sign() <= 0 || c.Cmp(key.NToSPlusOne()) >= 0 {
+			return nil, fmt.Errorf("invalid encrypted share of participant %d", j)
+		}
+		gamma, err := randomInt(pp.random, maskBound)
+		if err != nil {
+			return nil, err
+		}
+		encGamma, _, err := key.Encrypt(gamma)
+		if err != nil {
+			return nil, err
+		}
+		product, err := key.ScalarMul(c, pp.right)
+		if err != nil {
+			return nil, err
+		}
+		if responses[j], err = key.Add(product, encGamma); err != nil {
+			return nil, err
+		}
+		pp.mtaShare.Sub(pp.mtaShare, gamma)
+	}
+	return responses, nil
+}
+
+// finishMtA decrypts the responses to the left value of the participant and
+// returns its additive share of the sum of every product a_i*b_j, that is
+// a_i*b_i, plus the decrypted responses, minus the masks of its own
+// responses. The responses are decoded as integers in (-n/2, n/2) of the
+// auxiliary n.
+func (pp *PaillierParty) finishMtA(responses map[int]*big.Int) (*big.Int, error) {
+	share := new(big.Int).Set(pp.mtaShare)
+	half := new(big.Int).Rsh(pp.aux.N, 1)
+	for j := 1; j <= pp.params.Participants; j++ {
+		if j == pp.index {
+			continue
+		}
+		c, ok := responses[j]
+		if !ok || c == nil {
+			return nil, fmt.Errorf("missing response of participant %d", j)
+		}
+		value, err := pp.aux.Decrypt(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response of participant %d: %w", j, err)
+		}
+		if value.Cmp(half) > 0 {
+			value.Sub(value, pp.aux.N)
+		}
+		share.Add(share, value)
+	}
+	return share, nil
+}
+
+// CheckModulus adds the modulus shares of every participant and checks that
+// the resulting candidate n has the expected size and no factor below
+// trialDivisionBound.
+func CheckModulus(params PaillierParams, shares []*big.Int) (*big.Int, error) {
+	if len(shares) != params.Participants {
+		return nil, fmt.Errorf("expected %d modulus shares, got %d", params.Participants, len(shares))
+	}
+	n := big.NewInt(0)
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("missing modulus share of participant %d", i+1)
+		}
+		n.Add(n, share)
+	}
+	if n.BitLen() != params.BitSize || n.Bit(0) == 0 {
+		return nil, fmt.Errorf("modulus must be an odd number of %d bits", params.BitSize)
+	}
+	mod := new(big.Int)
+	for _, prime := range smallPrimes {
+		if mod.Mod(n, big.NewInt(prime)).Sign() == 0 {
+			return nil, fmt.Errorf("modulus is divisible by %d", prime)
+		}
+	}
+	return n, nil
+}
+
+// BiprimalityChallenges derives the challenges of the biprimality test of n
+// from n itself, so no participant can choose them: biprimalityRounds values
+// g with Jacobi symbol (g/n) = 1.
+func BiprimalityChallenges(n *big.Int) []*big.Int {
+	challenges := make([]*big.Int, 0, biprimalityRounds)
+	for counter := uint32(0); len(challenges) < biprimalityRounds; counter++ {
+		g := hashToInt(n, counter, n)
+		if g.Sign() > 0 && big.Jacobi(g, n) == 1 {
+			challenges = append(challenges, g)
+		}
+	}
+	return challenges
+}
+
+// CheckBiprimality runs the Boneh-Franklin biprimality test of n with the
+// shares of every participant (shares[i-1] for the participant i), that is,
+// it checks that g^(phi/4) = v_1 / prod(v_i) is 1 or -1 mod n for every
+// challenge g, which holds for every g when n is the product of two primes
+// that are 3 mod 4. The test does not rule out moduli with repeated factors.
+func CheckBiprimality(n *big.Int, challenges []*big.Int, shares [][]*big.Int) error {
+	if len(shares) < 2 {
+		return fmt.Errorf("at least two participants are required")
+	}
+	minusOne := new(big.Int).Sub(n, big.NewInt(1))
+	for round, g := range challenges {
+		if new(big.Int).GCD(nil, nil, g, n).Cmp(big.NewInt(1)) != 0 {
+			return fmt.Errorf("challenge %d is not coprime with n", round)
+		}
+		others := big.NewInt(1)
+		for i, s := range shares {
+			if len(s) != len(challenges) || s[round] == nil || s[round].Sign() <= 0 || s[round].Cmp(n) >= 0 {
+				return fmt.Errorf("invalid biprimality share of participant %d", i+1)
+			}
+			if i > 0 {
+				others.Mul(others, s[round]).Mod(others, n)
+			}
+		}
+		othersInv := new(big.Int).ModInverse(others, n)
+		if othersInv == nil {
+			return fmt.Errorf("biprimality shares of round %d are not invertible", round)
+		}
+		v := othersInv.Mul(othersInv, shares[0][round]).Mod(othersInv, n)
+		if v.Cmp(big.NewInt(1)) != 0 && v.Cmp(minusOne) != 0 {
+			return fmt.Errorf("modulus is not the product of two primes")
+		}
+	}
+	return nil
+}
+
+// NewPaillierPubKey returns the tcpaillier public key of a modulus n
+// generated by the participants, with s = 1, the verification keys of every
+// participant (verificationKeys[i-1] for the participant i) and V derived
+// from n.
+func NewPaillierPubKey(params PaillierParams, n *big.Int, verificationKeys []*big.Int) (*tcpaillier.PubKey, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if n == nil || n.BitLen() != params.BitSize {
+		return nil, fmt.Errorf("modulus must have %d bits", params.BitSize)
+	}
+	if len(verificationKeys) != params.Participants {
+		return nil, fmt.Errorf("expected %d verification keys, got %d", params.Participants, len(verificationKeys))
+	}
+	v, err := verificationBase(n)
+	if err != nil {
+		return nil, err
+	}
+	nSquare := new(big.Int).Mul(n, n)
+	vi := make([]*big.Int, len(verificationKeys))
+	for i, vk := range verificationKeys {
+		if vk == nil || vk.Sign() <= 0 || vk.Cmp(nSquare) >= 0 {
+			return nil, fmt.Errorf("invalid verification key of participant %d", i+1)
+		}
+		vi[i] = new(big.Int).Set(vk)
+	}
+	pk := &tcpaillier.PubKey{
+		N:  new(big.Int).Set(n),
+		V:  v,
+		Vi: vi,
+		L:  uint8(params.Participants),
+		K:  uint8(params.Threshold),
+		S:  1,
+	}
+	// delta = l!
+	pk.Delta = new(big.Int).MulRange(1, int64(params.Participants))
+	// constant = (4 * delta^2)^-1 mod n
+	pk.Constant = new(big.Int).Mul(pk.Delta, pk.Delta)
+	pk.Constant.Mul(pk.Constant, big.NewInt(4))
+	if pk.Constant.ModInverse(pk.Constant, n) == nil {
+		return nil, fmt.Errorf("4 * delta^2 has no inverse mod n")
+	}
+	return pk, nil
+}
+
+// verificationBase derives the base V of the verification keys from n, as a
+// random square mod n^2 coprime with n.
+func verificationBase(n *big.Int) (*big.Int, error) {
+	nSquare := new(big.Int).Mul(n, n)
+	for counter := uint32(0); counter < 256; counter++ {
+		r := hashToInt(n, counter|1<<31, nSquare)
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, n).Cmp(big.NewInt(1)) == 0 {
+			return r.Mul(r, r).Mod(r, nSquare), nil
+		}
+	}
+	return nil, fmt.Errorf("could not derive the verification base")
+}
+
+// hashToInt expands SHA-256(n || counter || block) to twice the size of the
+// modulus provided and reduces it mod the modulus.
+func hashToInt(n *big.Int, counter uint32, modulus *big.Int) *big.Int {
+	seed := binary.BigEndian.AppendUint32(n.Bytes(), counter)
+	size := 2 * ((modulus.BitLen() + 7) / 8)
+	buf := make([]byte, 0, size+sha256.Size)
+	for block := uint32(0); len(buf) < size; block++ {
+		digest := sha256.Sum256(binary.BigEndian.AppendUint32(append([]byte{}, seed...), block))
+		buf = append(buf, digest[:]...)
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(buf[:size]), modulus)
+}
@@ -0,0 +1,232 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/paillier"
+)
+
+// runPaillierKeyGen runs every round of the distributed Paillier key
+// generation with new participants, sampling candidates until a modulus
+// passes the tests, and returns the key share of every participant.
+func runPaillierKeyGen(t *testing.T, params PaillierParams) []*tcpaillier.KeyShare {
+	parties := make([]*PaillierParty, params.Participants)
+	auxKeys := make([]*paillier.PublicKey, params.Participants)
+	for i := range parties {
+		var err error
+		if parties[i], err = NewPaillierParty(rand.Reader, params, i+1); err != nil {
+			t.Fatalf("Error creating party: %v\n", err)
+		}
+		auxKeys[i] = parties[i].AuxKey()
+	}
+	// multiply runs a round of multiplications, start returns the encrypted
+	// shares and respond and finish are the rounds of every party
+	multiply := func(start func(*PaillierParty) (*big.Int, error),
+		respond func(*PaillierParty, []*paillier.PublicKey, map[int]*big.Int) (map[int]*big.Int, error),
+		finish func(*PaillierParty, map[int]*big.Int) (*big.Int, error),
+	) []*big.Int {
+		encShares := make(map[int]*big.Int)
+		for _, pp := range parties {
+			c, err := start(pp)
+			if err != nil {
+				t.Fatalf("Error starting multiplication: %v\n", err)
+			}
+			encShares[pp.Index()] = c
+		}
+		// responses[j][i] is the response of i to j
+		responses := make(map[int]map[int]*big.Int)
+		for _, pp := range parties {
+			out, err := respond(pp, auxKeys, encShares)
+			if err != nil {
+				t.Fatalf("Error multiplying: %v\n", err)
+			}
+			for j, c := range out {
+				if responses[j] == nil {
+					responses[j] = make(map[int]*big.Int)
+				}
+				responses[j][pp.Index()] = c
+			}
+		}
+		shares := make([]*big.Int, len(parties))
+		for i, pp := range parties {
+			var err error
+			if shares[i], err = finish(pp, responses[pp.Index()]); err != nil {
+				t.Fatalf("Error finishing multiplication: %v\n", err)
+			}
+		}
+		return shares
+	}
+	var n *big.Int
+	for attempts := 0; n == nil; attempts++ {
+		if attempts > 100000 {
+			t.Fatal("Too many candidates rejected")
+		}
+		shares := multiply((*PaillierParty).SampleCandidate, (*PaillierParty).MultiplyCandidate,
+			(*PaillierParty).ModulusShare)
+		candidate, err := CheckModulus(params, shares)
+		if err != nil {
+			continue
+		}
+		challenges := BiprimalityChallenges(candidate)
+		biprimalityShares := make([][]*big.Int, len(parties))
+		for i, pp := range parties {
+			if biprimalityShares[i], err = pp.BiprimalityShares(candidate, challenges); err != nil {
+				t.Fatalf("Error computing biprimality shares: %v\n", err)
+			}
+		}
+		if CheckBiprimality(candidate, challenges, biprimalityShares) == nil {
+			n = candidate
+		}
+	}
+	thetaShares := multiply(func(pp *PaillierParty) (*big.Int, error) { return pp.SampleExponent(n) },
+		(*PaillierParty).MultiplyExponent, (*PaillierParty).ExponentShare)
+	// reshared[j][i] is the share of i to j
+	reshared := make(map[int]map[int]*big.Int)
+	for _, pp := range parties {
+		shares, err := pp.Reshare(thetaShares)
+		if err != nil {
+			t.Fatalf("Error resharing: %v\n", err)
+		}
+		for j, share := range shares {
+			if reshared[j] == nil {
+				reshared[j] = make(map[int]*big.Int)
+			}
+			reshared[j][pp.Index()] = share
+		}
+	}
+	verificationKeys := make([]*big.Int, len(parties))
+	for i, pp := range parties {
+		var err error
+		if verificationKeys[i], err = pp.Finalize(reshared[pp.Index()]); err != nil {
+			t.Fatalf("Error finalizing: %v\n", err)
+		}
+	}
+	keyShares := make([]*tcpaillier.KeyShare, len(parties))
+	for i, pp := range parties {
+		var err error
+		if keyShares[i], err = pp.KeyShare(verificationKeys); err != nil {
+			t.Fatalf("Error getting key share: %v\n", err)
+		}
+	}
+	return keyShares
+}
+
+func TestPaillierKeyGen(t *testing.T) {
+	params := PaillierParams{BitSize: 96, Participants: 3, Threshold: 2}
+	keyShares := runPaillierKeyGen(t, params)
+	pk := keyShares[0].PubKey
+	if pk.N.BitLen() != params.BitSize {
+		t.Fatalf("Expected a modulus of %d bits, got %d\n", params.BitSize, pk.N.BitLen())
+	}
+	// every participant must build the same public key
+	for _, ks := range keyShares[1:] {
+		if ks.PubKey.N.Cmp(pk.N) != 0 || ks.PubKey.V.Cmp(pk.V) != 0 {
+			t.Fatalf("Public key of participant %d does not match\n", ks.Index)
+		}
+	}
+	m1, m2 := big.NewInt(1234), big.NewInt(5678)
+	c1, _, err := pk.Encrypt(m1)
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	c2, _, err := pk.Encrypt(m2)
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	sum, err := pk.Add(c1, c2)
+	if err != nil {
+		t.Fatalf("Error adding: %v\n", err)
+	}
+	expected := new(big.Int).Add(m1, m2)
+	// every subset of threshold participants must decrypt
+	for _, subset := range [][]int{{0, 1}, {0, 2}, {1, 2}} {
+		decShares := make([]*tcpaillier.DecryptionShare, len(subset))
+		for i, idx := range subset {
+			ds, zk, err := keyShares[idx].PartialDecryptWithProof(sum)
+			if err != nil {
+				t.Fatalf("Error decrypting partially: %v\n", err)
+			}
+			if err := zk.Verify(pk, sum, ds); err != nil {
+				t.Fatalf("Invalid decryption proof of participant %d: %v\n", idx+1, err)
+			}
+			decShares[i] = ds
+		}
+		dec, err := pk.CombineShares(decShares...)
+		if err != nil {
+			t.Fatalf("Error combining shares: %v\n", err)
+		}
+		if dec.Cmp(expected) != 0 {
+			t.Errorf("Expected %v with participants %v, got %v\n", expected, subset, dec)
+		}
+	}
+}
+
+func TestCheckBiprimality(t *testing.T) {
+	// the shares of the participant 2 are 0, so the participant 1 holds p
+	// and q
+	shares := func(p, q *big.Int) (*big.Int, [][]*big.Int) {
+		n := new(big.Int).Mul(p, q)
+		challenges := BiprimalityChallenges(n)
+		exp := new(big.Int).Add(n, big.NewInt(1))
+		exp.Sub(exp, p).Sub(exp, q).Rsh(exp, 2)
+		s := [][]*big.Int{make([]*big.Int, len(challenges)), make([]*big.Int, len(challenges))}
+		for i, g := range challenges {
+			s[0][i] = new(big.Int).Exp(g, exp, n)
+			s[1][i] = big.NewInt(1)
+		}
+		return n, s
+	}
+	// 2147483659 and 2147483743 are primes and 4294967299 = 7 * 613566757
+	// is not, all of them are 3 mod 4
+	p, q := big.NewInt(2147483659), big.NewInt(2147483743)
+	n, s := shares(p, q)
+	if err := CheckBiprimality(n, BiprimalityChallenges(n), s); err != nil {
+		t.Errorf("Error testing the product of two primes: %v\n", err)
+	}
+	n, s = shares(p, big.NewInt(4294967299))
+	if err := CheckBiprimality(n, BiprimalityChallenges(n), s); err == nil {
+		t.Error("Expected error testing a modulus with a composite factor")
+	}
+	params := PaillierParams{BitSize: 64, Participants: 2, Threshold: 2}
+	if _, err := CheckModulus(params, []*big.Int{n, big.NewInt(0)}); err == nil {
+		t.Error("Expected error checking a modulus divisible by 7")
+	}
+}
+
+func TestPaillierPartyInvalidInput(t *testing.T) {
+	for _, params := range []PaillierParams{
+		{BitSize: 127, Participants: 3, Threshold: 2},
+		{BitSize: 128, Participants: 1, Threshold: 1},
+		{BitSize: 128, Participants: 4, Threshold: 2},
+	} {
+		if _, err := NewPaillierParty(rand.Reader, params, 1); err == nil {
+			t.Errorf("Expected error with params %+v\n", params)
+		}
+	}
+	params := PaillierParams{BitSize: 128, Participants: 3, Threshold: 2}
+	if _, err := NewPaillierParty(rand.Reader, params, 4); err == nil {
+		t.Error("Expected error creating a party out of range")
+	}
+	pp, err := NewPaillierParty(rand.Reader, params, 1)
+	if err != nil {
+		t.Fatalf("Error creating party: %v\n", err)
+	}
+	if _, err := pp.ModulusShare(nil); err == nil {
+		t.Error("Expected error sharing the modulus before sampling")
+	}
+	if _, err := pp.Reshare(nil); err == nil {
+		t.Error("Expected error resharing before multiplying")
+	}
+	if _, err := pp.SampleCandidate(); err != nil {
+		t.Fatalf("Error sampling candidate: %v\n", err)
+	}
+	if _, err := pp.MultiplyCandidate(nil, nil); err == nil {
+		t.Error("Expected error multiplying without auxiliary keys")
+	}
+	if _, err := pp.ModulusShare(map[int]*big.Int{}); err == nil {
+		t.Error("Expected error sharing the modulus without responses")
+	}
+}
@@ -0,0 +1,389 @@
+package dkg
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Params holds the public parameters of a DKG: the group of prime order q
+// generated by g, the number of participants and the threshold, that is the
+// number of shares required to reconstruct the joint secret.
+type Params struct {
+	Group        Group
+	Participants int
+	Threshold    int
+}
+
+// validate checks that the parameters are consistent.
+func (p Params) validate() error {
+	if p.Group == nil {
+		return fmt.Errorf("missing group")
+	}
+	if p.Participants < 1 {
+		return fmt.Errorf("participants must be positive, got %d", p.Participants)
+	}
+	if p.Threshold < 1 || p.Threshold > p.Participants {
+		return fmt.Errorf("threshold must be between 1 and %d, got %d", p.Participants, p.Threshold)
+	}
+	return nil
+}
+
+// Deal is the contribution of a dealer to the DKG. The commitments are
+// broadcast to every participant, while every share must be sent privately
+// to its recipient, indexed by the recipient index.
+type Deal struct {
+	Dealer      int
+	Commitments []*Commitment
+	Shares      map[int]*big.Int
+}
+
+// Complaint is broadcast by a participant that received an invalid share, or
+// no share at all, from a dealer.
+type Complaint struct {
+	Complainer int
+	Dealer     int
+}
+
+// Justification is broadcast by a dealer in response to a complaint against
+// it, revealing the disputed share so every participant can check it against
+// the commitments of the dealer.
+type Justification struct {
+	Dealer     int
+	Complainer int
+	Share      *big.Int
+}
+
+// KeyShare is the result of the DKG for a participant: its share of the
+// joint secret, the group public key g^x and the verification key g^x_i of
+// every participant (VerificationKeys[i-1] for participant i), computed from
+// the commitments of the qualified dealers.
+type KeyShare struct {
+	Index            int
+	Secret           *big.Int
+	PublicKey        *big.Int
+	VerificationKeys []*big.Int
+	Qualified        []int
+}
+
+// participant states, the rounds must be run in this order
+const (
+	stateNew = iota
+	stateDealt
+	stateVerified
+	stateFinalized
+)
+
+// Participant runs the rounds of a Pedersen DKG with Feldman commitments for
+// a single participant: Deal, Receive (once per dealer), Verify, Justify the
+// complaints against it, and Finalize with the complaints and justifications
+// of every participant. It is not safe for concurrent use.
+type Participant struct {
+	params      Params
+	index       int
+	random      io.Reader
+	state       int
+	poly        []*big.Int
+	encSecret   *big.Int
+	commitments map[int][]*Commitment
+	shares      map[int]*big.Int
+}
+
+// NewParticipant creates the participant with the index provided, between 1
+// and params.Participants, and generates its secret polynomial and the secret
+// key to receive encrypted shares. The randomness source provided is also
+// used to encrypt the shares in DealMessage.
+func NewParticipant(random io.Reader, params Params, index int) (*Participant, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if index < 1 || index > params.Participants {
+		return nil, fmt.Errorf("index must be between 1 and %d, got %d", params.Participants, index)
+	}
+	poly, err := GenerateRandomPolynomial(random, params.Threshold, params.Group.Order())
+	if err != nil {
+		return nil, err
+	}
+	encSecret, err := RandomScalar(random, params.Group)
+	if err != nil {
+		return nil, err
+	}
+	return &Participant{
+		params:      params,
+		index:       index,
+		random:      random,
+		poly:        poly,
+		encSecret:   encSecret,
+		commitments: make(map[int][]*Commitment),
+		shares:      make(map[int]*big.Int),
+	}, nil
+}
+
+// Index returns the index of the participant.
+func (pt *Participant) Index() int {
+	return pt.index
+}
+
+// Deal returns the commitments to the polynomial of the participant and the
+// share of every participant, including its own one, which is also received.
+func (pt *Participant) Deal() (*Deal, error) {
+	if pt.state != stateNew {
+		return nil, fmt.Errorf("participant %d already dealt", pt.index)
+	}
+	commitments, err := GenerateCommitments(pt.params.Group, pt.poly)
+	if err != nil {
+		return nil, err
+	}
+	deal := &Deal{
+		Dealer:      pt.index,
+		Commitments: commitments,
+		Shares:      make(map[int]*big.Int, pt.params.Participants),
+	}
+	for j := 1; j <= pt.params.Participants; j++ {
+		if deal.Shares[j], err = GenerateShare(j, pt.poly, pt.params.Group.Order()); err != nil {
+			return nil, err
+		}
+	}
+	pt.commitments[pt.index] = deal.Commitments
+	pt.shares[pt.index] = deal.Shares[pt.index]
+	pt.state = stateDealt
+	return deal, nil
+}
+
+// Receive stores the commitments broadcast by a dealer and the share that it
+// sent to this participant. The share is checked later in Verify.
+func (pt *Participant) Receive(dealer int, commitments []*Commitment, share *big.Int) error {
+	if share == nil {
+		return fmt.Errorf("nil share")
+	}
+	return pt.receive(dealer, commitments, share)
+}
+
+// receive stores the commitments of a dealer and the share received, if it is
+// not nil. Without a share the participant complains against the dealer in
+// Verify, but it can still check its justification.
+func (pt *Participant) receive(dealer int, commitments []*Commitment, share *big.Int) error {
+	if pt.state >= stateVerified {
+		return fmt.Errorf("participant %d already verified the deals", pt.index)
+	}
+	if dealer < 1 || dealer > pt.params.Participants {
+		return fmt.Errorf("dealer must be between 1 and %d, got %d", pt.params.Participants, dealer)
+	}
+	if dealer == pt.index {
+		return fmt.Errorf("participant %d can not receive its own deal", pt.index)
+	}
+	if _, ok := pt.commitments[dealer]; ok {
+		return fmt.Errorf("deal of dealer %d already received", dealer)
+	}
+	if len(commitments) != pt.params.Threshold {
+		return fmt.Errorf("expected %d commitments, got %d", pt.params.Threshold, len(commitments))
+	}
+	if err := checkCommitments(pt.params.Group, commitments); err != nil {
+		return fmt.Errorf("invalid commitments of dealer %d: %w", dealer, err)
+	}
+	pt.commitments[dealer] = commitments
+	if share != nil {
+		pt.shares[dealer] = share
+	}
+	return nil
+}
+
+// Verify checks the share received from every dealer against its
+// commitments, all at once with BatchVerifyShares, and returns a complaint
+// against every dealer whose share is invalid or missing. The complaints must
+// be broadcast to every participant.
+func (pt *Participant) Verify() ([]Complaint, error) {
+	if pt.state != stateDealt {
+		return nil, fmt.Errorf("participant %d must deal before verifying", pt.index)
+	}
+	// the shares received are verified at once, and the missing ones are
+	// complained against directly
+	complained := make(map[int]bool)
+	var dealtShares []*DealtShare
+	for dealer := 1; dealer <= pt.params.Participants; dealer++ {
+		share, ok := pt.shares[dealer]
+		if !ok {
+			complained[dealer] = true
+			continue
+		}
+		dealtShares = append(dealtShares, &DealtShare{
+			Dealer:      dealer,
+			Recipient:   pt.index,
+			Share:       share,
+			Commitments: pt.commitments[dealer],
+		})
+	}
+	invalid, err := BatchVerifyShares(pt.random, pt.params.Group, dealtShares)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range invalid {
+		complained[dealtShares[t].Dealer] = true
+	}
+	complaints := []Complaint{}
+	for dealer := 1; dealer <= pt.params.Participants; dealer++ {
+		if complained[dealer] {
+			complaints = append(complaints, Complaint{Complainer: pt.index, Dealer: dealer})
+		}
+	}
+	pt.state = stateVerified
+	return complaints, nil
+}
+
+// Justify returns the justification of every complaint against the
+// participant among the complaints provided, revealing the disputed shares.
+// The justifications must be broadcast to every participant.
+func (pt *Participant) Justify(complaints []Complaint) ([]Justification, error) {
+	if pt.state != stateVerified {
+		return nil, fmt.Errorf("participant %d must verify before justifying", pt.index)
+	}
+	justifications := []Justification{}
+	for _, c := range complaints {
+		if c.Dealer != pt.index || !pt.validDispute(c.Dealer, c.Complainer) {
+			continue
+		}
+		share, err := GenerateShare(c.Complainer, pt.poly, pt.params.Group.Order())
+		if err != nil {
+			return nil, err
+		}
+		justifications = append(justifications, Justification{
+			Dealer:     pt.index,
+			Complainer: c.Complainer,
+			Share:      share,
+		})
+	}
+	return justifications, nil
+}
+
+// Finalize computes the key share of the participant from the deals of the
+// qualified dealers (QUAL). A dealer is disqualified if any complaint against
+// it, among the complaints of every participant provided, has no
+// justification with a share that matches its commitments. Complaints out of
+// range or of a dealer against itself are ignored. QUAL is built
+// over every dealer from the broadcast complaints and justifications only,
+// so it is the same for every participant, and it is an error if the
+// commitments of a dealer are missing to check a justification or to compute
+// the key. If a complaint of this participant is justified, it takes the
+// revealed share. At least threshold dealers must qualify.
+func (pt *Participant) Finalize(complaints []Complaint, justifications []Justification) (*KeyShare, error) {
+	if pt.state != stateVerified {
+		return nil, fmt.Errorf("participant %d must verify before finalizing", pt.index)
+	}
+	// index the valid justifications by dealer and complainer
+	type dispute struct{ dealer, complainer int }
+	justified := make(map[dispute]*big.Int)
+	for _, j := range justifications {
+		if j.Share == nil || !pt.validDispute(j.Dealer, j.Complainer) {
+			continue
+		}
+		commitments, ok := pt.commitments[j.Dealer]
+		if !ok {
+			return nil, fmt.Errorf("missing commitments of dealer %d to check its justification", j.Dealer)
+		}
+		if VerifyShare(pt.params.Group, j.Share, j.Complainer, commitments) == nil {
+			justified[dispute{j.Dealer, j.Complainer}] = j.Share
+		}
+	}
+	// the complaints that no dealer can justify are ignored, otherwise anyone
+	// could disqualify an honest dealer
+	disqualified := make(map[int]bool)
+	for _, c := range complaints {
+		if !pt.validDispute(c.Dealer, c.Complainer) {
+			continue
+		}
+		share, ok := justified[dispute{c.Dealer, c.Complainer}]
+		if !ok {
+			disqualified[c.Dealer] = true
+			continue
+		}
+		if c.Complainer == pt.index {
+			pt.shares[c.Dealer] = share
+		}
+	}
+	qualified := []int{}
+	for dealer := 1; dealer <= pt.params.Participants; dealer++ {
+		if disqualified[dealer] {
+			continue
+		}
+		if _, ok := pt.commitments[dealer]; !ok {
+			return nil, fmt.Errorf("missing commitments of qualified dealer %d", dealer)
+		}
+		if _, ok := pt.shares[dealer]; !ok {
+			return nil, fmt.Errorf("missing share of qualified dealer %d", dealer)
+		}
+		qualified = append(qualified, dealer)
+	}
+	if len(qualified) < pt.params.Threshold {
+		return nil, fmt.Errorf("only %d qualified dealers, at least %d required", len(qualified), pt.params.Threshold)
+	}
+	group := pt.params.Group
+	q := group.Order()
+	secret := big.NewInt(0)
+	publicKey := group.Identity()
+	for _, dealer := range qualified {
+		secret.Add(secret, pt.shares[dealer]).Mod(secret, q)
+		publicKey = group.Mul(publicKey, pt.commitments[dealer][0].value)
+	}
+	// the verification key of participant j is the product of the
+	// evaluations of the committed polynomials at j
+	verificationKeys := make([]*big.Int, pt.params.Participants)
+	for j := range verificationKeys {
+		verificationKeys[j] = group.Identity()
+		for _, dealer := range qualified {
+			verificationKeys[j] = group.Mul(verificationKeys[j], evalCommitments(group, pt.commitments[dealer], j+1))
+		}
+	}
+	pt.state = stateFinalized
+	return &KeyShare{
+		Index:            pt.index,
+		Secret:           secret,
+		PublicKey:        publicKey,
+		VerificationKeys: verificationKeys,
+		Qualified:        qualified,
+	}, nil
+}
+
+// validDispute checks that the dealer and the complainer of a complaint or a
+// justification are different participants.
+func (pt *Participant) validDispute(dealer, complainer int) bool {
+	return dealer >= 1 && dealer <= pt.params.Participants &&
+		complainer >= 1 && complainer <= pt.params.Participants && dealer != complainer
+}
+
+// evalCommitments returns g^f(i) from the commitments to the coefficients of
+// f, that is, the product of C_k^(i^k).
+func evalCommitments(group Group, commitments []*Commitment, i int) *big.Int {
+	result := group.Identity()
+	q := group.Order()
+	x := big.NewInt(int64(i))
+	xk := big.NewInt(1)
+	for _, c := range commitments {
+		result = group.Mul(result, group.Exp(c.value, xk))
+		xk.Mul(xk, x).Mod(xk, q)
+	}
+	return result
+}
+
+// GenerateRandomPolynomial generates a random polynomial of degree k-1 with a
+// random non-zero constant term, which is the secret of the dealer, reading
+// the coefficients from the randomness source provided.
+func GenerateRandomPolynomial(random io.Reader, k int, q *big.Int) ([]*big.Int, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("degree must be positive, got %d", k-1)
+	}
+	coeffs := make([]*big.Int, k)
+	for i := range coeffs {
+		var err error
+		if coeffs[i], err = randomInt(random, q); err != nil {
+			return nil, err
+		}
+	}
+	// the constant term must be in [1, q)
+	for coeffs[0].Sign() == 0 {
+		var err error
+		if coeffs[0], err = randomInt(random, q); err != nil {
+			return nil, err
+		}
+	}
+	return coeffs, nil
+}
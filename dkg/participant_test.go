@@ -0,0 +1,319 @@
+package dkg
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// testParams returns DKG parameters over the group provided.
+func testParams(group Group, participants, threshold int) Params {
+	return Params{
+		Group:        group,
+		Participants: participants,
+		Threshold:    threshold,
+	}
+}
+
+// runDKG runs every round of the DKG with the participants provided. The
+// share sent by every dealer to every recipient can be tampered with the
+// tamper function, and the justifications with the justify function, which
+// drops them when it returns nil.
+func runDKG(t *testing.T, participants []*Participant, tamper func(dealer, recipient int, share *big.Int) *big.Int,
+	justify func(Justification) *Justification,
+) []*KeyShare {
+	deals := make([]*Deal, len(participants))
+	for i, pt := range participants {
+		var err error
+		if deals[i], err = pt.Deal(); err != nil {
+			t.Fatalf("Error dealing: %v\n", err)
+		}
+	}
+	for _, deal := range deals {
+		for _, pt := range participants {
+			if pt.Index() == deal.Dealer {
+				continue
+			}
+			share := tamper(deal.Dealer, pt.Index(), deal.Shares[pt.Index()])
+			if err := pt.Receive(deal.Dealer, deal.Commitments, share); err != nil {
+				t.Fatalf("Error receiving: %v\n", err)
+			}
+		}
+	}
+	complaints := []Complaint{}
+	for _, pt := range participants {
+		c, err := pt.Verify()
+		if err != nil {
+			t.Fatalf("Error verifying: %v\n", err)
+		}
+		complaints = append(complaints, c...)
+	}
+	justifications := []Justification{}
+	for _, pt := range participants {
+		js, err := pt.Justify(complaints)
+		if err != nil {
+			t.Fatalf("Error justifying: %v\n", err)
+		}
+		for _, j := range js {
+			if tampered := justify(j); tampered != nil {
+				justifications = append(justifications, *tampered)
+			}
+		}
+	}
+	keyShares := make([]*KeyShare, len(participants))
+	for i, pt := range participants {
+		var err error
+		if keyShares[i], err = pt.Finalize(complaints, justifications); err != nil {
+			t.Fatalf("Error finalizing: %v\n", err)
+		}
+	}
+	return keyShares
+}
+
+// checkKeyShares checks that the key shares provided are consistent and that
+// the expected dealers qualified.
+func checkKeyShares(t *testing.T, params Params, keyShares []*KeyShare, qualified []int) {
+	for _, ks := range keyShares {
+		if ks.PublicKey.Cmp(keyShares[0].PublicKey) != 0 {
+			t.Fatalf("Participant %d got a different public key\n", ks.Index)
+		}
+		if fmt.Sprint(ks.Qualified) != fmt.Sprint(qualified) {
+			t.Fatalf("Unexpected qualified dealers: expected %v, got %v\n", qualified, ks.Qualified)
+		}
+		// the verification key of every participant matches its share
+		expected := params.Group.Exp(params.Group.Generator(), ks.Secret)
+		if ks.VerificationKeys[ks.Index-1].Cmp(expected) != 0 {
+			t.Errorf("Unexpected verification key of participant %d\n", ks.Index)
+		}
+	}
+	// any threshold shares reconstruct the non zero joint secret
+	secret, err := LagrangeInterpolation(
+		[]*big.Int{keyShares[1].Secret, keyShares[3].Secret, keyShares[4].Secret}, []int{2, 4, 5}, params.Group.Order())
+	if err != nil {
+		t.Fatalf("Error reconstructing secret: %v\n", err)
+	}
+	if secret.Sign() == 0 {
+		t.Error("Unexpected zero joint secret")
+	}
+	if params.Group.Exp(params.Group.Generator(), secret).Cmp(keyShares[0].PublicKey) != 0 {
+		t.Error("Reconstructed secret does not match the public key")
+	}
+}
+
+// newParticipants creates every participant of the params provided.
+func newParticipants(t *testing.T, params Params) []*Participant {
+	participants := make([]*Participant, params.Participants)
+	for i := range participants {
+		var err error
+		if participants[i], err = NewParticipant(rand.Reader, params, i+1); err != nil {
+			t.Fatalf("Error creating participant: %v\n", err)
+		}
+	}
+	return participants
+}
+
+func TestParticipant(t *testing.T) {
+	q, p, err := GenerateSafePrime(context.Background(), rand.Reader, 256)
+	if err != nil {
+		t.Fatalf("Error generating safe prime: %v\n", err)
+	}
+	g, err := FindGenerator(rand.Reader, p, q)
+	if err != nil {
+		t.Fatalf("Error finding generator: %v\n", err)
+	}
+	custom, err := NewMODPGroup(p, g)
+	if err != nil {
+		t.Fatalf("Error creating group: %v\n", err)
+	}
+	for _, group := range []Group{BabyJubJub, FFDHE2048, custom} {
+		t.Run(group.Name(), func(t *testing.T) {
+			params := testParams(group, 5, 3)
+			honest := func(j Justification) *Justification { return &j }
+			// the share from the dealer 2 to the participant 4 is corrupted,
+			// but the dealer justifies it and qualifies
+			participants := newParticipants(t, params)
+			keyShares := runDKG(t, participants, func(dealer, recipient int, share *big.Int) *big.Int {
+				if dealer == 2 && recipient == 4 {
+					return new(big.Int).Add(share, big.NewInt(1))
+				}
+				return share
+			}, honest)
+			checkKeyShares(t, params, keyShares, []int{1, 2, 3, 4, 5})
+			// the rounds can not be repeated
+			if _, err := participants[0].Deal(); err == nil {
+				t.Error("Expected error dealing twice")
+			}
+			if _, err := participants[0].Finalize(nil, nil); err == nil {
+				t.Error("Expected error finalizing twice")
+			}
+		})
+	}
+}
+
+func TestParticipantDisqualification(t *testing.T) {
+	params := testParams(BabyJubJub, 5, 3)
+	// the dealers 2 and 3 send invalid shares, the dealer 2 does not justify
+	// it and the dealer 3 reveals another invalid share
+	participants := newParticipants(t, params)
+	keyShares := runDKG(t, participants, func(dealer, recipient int, share *big.Int) *big.Int {
+		if (dealer == 2 && recipient == 4) || (dealer == 3 && recipient == 1) {
+			return new(big.Int).Add(share, big.NewInt(1))
+		}
+		return share
+	}, func(j Justification) *Justification {
+		switch j.Dealer {
+		case 2:
+			return nil
+		case 3:
+			j.Share = new(big.Int).Add(j.Share, big.NewInt(1))
+		}
+		return &j
+	})
+	checkKeyShares(t, params, keyShares, []int{1, 4, 5})
+}
+
+func TestParticipantMissingDeal(t *testing.T) {
+	params := testParams(BabyJubJub, 4, 2)
+	// the participant 4 never receives the deal of the dealer 1, which
+	// justifies the complaint, and the dealer 2 never deals to anyone
+	participants := newParticipants(t, params)
+	deals := make([]*Deal, len(participants))
+	for i, pt := range participants {
+		var err error
+		if deals[i], err = pt.Deal(); err != nil {
+			t.Fatalf("Error dealing: %v\n", err)
+		}
+	}
+	for _, deal := range deals {
+		for _, pt := range participants {
+			if pt.Index() == deal.Dealer || deal.Dealer == 2 || (deal.Dealer == 1 && pt.Index() == 4) {
+				continue
+			}
+			if err := pt.Receive(deal.Dealer, deal.Commitments, deal.Shares[pt.Index()]); err != nil {
+				t.Fatalf("Error receiving: %v\n", err)
+			}
+		}
+	}
+	complaints := []Complaint{}
+	for _, pt := range participants {
+		c, err := pt.Verify()
+		if err != nil {
+			t.Fatalf("Error verifying: %v\n", err)
+		}
+		complaints = append(complaints, c...)
+	}
+	justifications, err := participants[0].Justify(complaints)
+	if err != nil {
+		t.Fatalf("Error justifying: %v\n", err)
+	}
+	for _, pt := range participants[:3] {
+		ks, err := pt.Finalize(complaints, justifications)
+		if err != nil {
+			t.Fatalf("Error finalizing: %v\n", err)
+		}
+		if fmt.Sprint(ks.Qualified) != fmt.Sprint([]int{1, 3, 4}) {
+			t.Errorf("Unexpected qualified dealers of participant %d: %v\n", pt.Index(), ks.Qualified)
+		}
+	}
+	// without the commitments of the dealer 1 the participant 4 can not
+	// check its justification, and it fails instead of disqualifying it
+	if _, err := participants[3].Finalize(complaints, justifications); err == nil {
+		t.Error("Expected error finalizing without the commitments of a dealer")
+	}
+}
+
+func TestParticipantBogusComplaints(t *testing.T) {
+	params := testParams(BabyJubJub, 3, 2)
+	participants := newParticipants(t, params)
+	deals := make([]*Deal, len(participants))
+	for i, pt := range participants {
+		var err error
+		if deals[i], err = pt.Deal(); err != nil {
+			t.Fatalf("Error dealing: %v\n", err)
+		}
+	}
+	for _, deal := range deals {
+		for _, pt := range participants {
+			if pt.Index() == deal.Dealer {
+				continue
+			}
+			if err := pt.Receive(deal.Dealer, deal.Commitments, deal.Shares[pt.Index()]); err != nil {
+				t.Fatalf("Error receiving: %v\n", err)
+			}
+		}
+	}
+	// complaints that no dealer can justify are injected in the broadcast
+	complaints := []Complaint{
+		{Complainer: 0, Dealer: 1},
+		{Complainer: 99, Dealer: 2},
+		{Complainer: 3, Dealer: 3},
+		{Complainer: 1, Dealer: 4},
+	}
+	for _, pt := range participants {
+		c, err := pt.Verify()
+		if err != nil {
+			t.Fatalf("Error verifying: %v\n", err)
+		}
+		complaints = append(complaints, c...)
+	}
+	justifications := []Justification{}
+	for _, pt := range participants {
+		js, err := pt.Justify(complaints)
+		if err != nil {
+			t.Fatalf("Error justifying: %v\n", err)
+		}
+		justifications = append(justifications, js...)
+	}
+	if len(justifications) != 0 {
+		t.Errorf("Unexpected justifications %v\n", justifications)
+	}
+	for _, pt := range participants {
+		ks, err := pt.Finalize(complaints, justifications)
+		if err != nil {
+			t.Fatalf("Error finalizing: %v\n", err)
+		}
+		if fmt.Sprint(ks.Qualified) != fmt.Sprint([]int{1, 2, 3}) {
+			t.Errorf("Unexpected qualified dealers of participant %d: %v\n", pt.Index(), ks.Qualified)
+		}
+	}
+}
+
+func TestParticipantInvalidInput(t *testing.T) {
+	params := testParams(BabyJubJub, 3, 2)
+	if _, err := NewParticipant(rand.Reader, params, 4); err == nil {
+		t.Error("Expected error creating a participant out of range")
+	}
+	if _, err := NewParticipant(rand.Reader, Params{Group: params.Group, Participants: 3, Threshold: 4}, 1); err == nil {
+		t.Error("Expected error creating a participant with a threshold over the participants")
+	}
+	pt, err := NewParticipant(rand.Reader, params, 1)
+	if err != nil {
+		t.Fatalf("Error creating participant: %v\n", err)
+	}
+	if _, err := pt.Verify(); err == nil {
+		t.Error("Expected error verifying before dealing")
+	}
+	commitments, err := NewCommitments(params.Group, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	if err != nil {
+		t.Fatalf("Error creating commitments: %v\n", err)
+	}
+	for name, dealer := range map[string]int{"own deal": 1, "out of range": 4} {
+		if err := pt.Receive(dealer, commitments, big.NewInt(0)); err == nil {
+			t.Errorf("Expected error receiving %s\n", name)
+		}
+	}
+	if err := pt.Receive(2, commitments[:1], big.NewInt(0)); err == nil {
+		t.Error("Expected error receiving too few commitments")
+	}
+	if err := pt.Receive(2, []*Commitment{commitments[0], {}}, big.NewInt(0)); err == nil {
+		t.Error("Expected error receiving a commitment not validated")
+	}
+	if err := pt.Receive(2, commitments, big.NewInt(0)); err != nil {
+		t.Fatalf("Error receiving: %v\n", err)
+	}
+	if err := pt.Receive(2, commitments, big.NewInt(0)); err == nil {
+		t.Error("Expected error receiving the same deal twice")
+	}
+}
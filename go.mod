@@ -5,6 +5,7 @@ go 1.23.1
 require github.com/niclabs/tcpaillier v0.0.7
 
 require (
+	github.com/dchest/blake512 v1.0.0 // indirect
 	github.com/glendc/go-external-ip v0.1.0 // indirect
 	github.com/iden3/wasmer-go v0.0.1 // indirect
 	github.com/klauspost/cpuid/v2 v2.2.7 // indirect
@@ -0,0 +1,35 @@
+// Package subset enumerates the subsets of k elements of [0, n), used to find
+// a subset of shares that combines when some of them are bad.
+package subset
+
+// MaxTries bounds the number of subsets that are tried before giving up.
+const MaxTries = 1 << 12
+
+// First returns the first subset of k indices in lexicographic order,
+// [0, k).
+func First(k int) []int {
+	subset := make([]int, k)
+	for i := range subset {
+		subset[i] = i
+	}
+	return subset
+}
+
+// Next advances the subset of indices in [0, n) provided, in increasing
+// order, to the next one in lexicographic order, and returns false when it is
+// the last one.
+func Next(subset []int, n int) bool {
+	k := len(subset)
+	i := k - 1
+	for i >= 0 && subset[i] == n-k+i {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	subset[i]++
+	for j := i + 1; j < k; j++ {
+		subset[j] = subset[j-1] + 1
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package subset
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	subset := First(3)
+	count := 1
+	for Next(subset, 5) {
+		count++
+	}
+	// C(5, 3) subsets, ending with the last one
+	if count != 10 {
+		t.Errorf("Expected 10 subsets, got %d\n", count)
+	}
+	if subset[0] != 2 || subset[1] != 3 || subset[2] != 4 {
+		t.Errorf("Expected the last subset [2 3 4], got %v\n", subset)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/tally"
 )
 
 func main() {
@@ -53,19 +54,22 @@ func main() {
 		encryptedSum = homomorphicAdd(encryptedSum, encryptedValues[i], pk.N, s)
 	}
 
-	// Step 4: Partial decrypt with each share
-	decryptionShares := make([]*tcpaillier.DecryptionShare, l)
+	// Step 4: Partial decrypt with each share, proving every decryption share
+	decryptionShares := make([]*tally.VerifiedShare, l)
 	for i, share := range shares {
-		decryptShare, err := share.PartialDecrypt(encryptedSum)
+		decryptShare, proof, err := share.PartialDecryptWithProof(encryptedSum)
 		if err != nil {
 			fmt.Printf("Error decrypting share %d: %v\n", i+1, err)
 			return
 		}
-		decryptionShares[i] = decryptShare
+		decryptionShares[i] = &tally.VerifiedShare{Share: decryptShare, Proof: proof}
 	}
 
-	// Step 5: Combine the shares to get the final decrypted sum
-	decryptedSum, err := pk.CombineShares(decryptionShares...)
+	// Step 5: Verify and combine the shares to get the final decrypted sum
+	decryptedSum, invalid, err := tally.CombineVerified(pk, encryptedSum, decryptionShares...)
+	if len(invalid) > 0 {
+		fmt.Printf("Invalid decryption shares of trustees %v\n", invalid)
+	}
 	if err != nil {
 		fmt.Printf("Error combining shares: %v\n", err)
 		return
@@ -0,0 +1,83 @@
+// Package nullifier derives the commitments and nullifiers of the voters like
+// the VocdoniZ circuit does, and keeps a registry of the nullifiers used in an
+// election to detect double votes.
+//
+// The commitment of a voter is Poseidon(address, processID, secret) and its
+// nullifier is Poseidon(commitment, secret), where every value is reduced to
+// an element of the BN254 scalar field.
+package nullifier
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"go.vocdoni.io/dvote/util"
+)
+
+// Address is the address of a voter.
+type Address []byte
+
+// FF returns the address as an element of the circuit field.
+func (a Address) FF() *big.Int {
+	return bytesToFF(a)
+}
+
+// ProcessID is the identifier of an election process.
+type ProcessID []byte
+
+// FF returns the process ID as an element of the circuit field.
+func (p ProcessID) FF() *big.Int {
+	return bytesToFF(p)
+}
+
+// Secret is the secret of a voter, only known by them.
+type Secret []byte
+
+// FF returns the secret as an element of the circuit field.
+func (s Secret) FF() *big.Int {
+	return bytesToFF(s)
+}
+
+// bytesToFF interprets the bytes provided as a big-endian number and reduces
+// it to an element of the circuit field.
+func bytesToFF(b []byte) *big.Int {
+	return util.BigToFF(new(big.Int).SetBytes(b))
+}
+
+// Commitment returns the commitment of the voter address, the process ID and
+// the voter secret provided.
+func Commitment(address Address, processID ProcessID, secret Secret) (*big.Int, error) {
+	commitment, err := poseidon.Hash([]*big.Int{address.FF(), processID.FF(), secret.FF()})
+	if err != nil {
+		return nil, fmt.Errorf("error hashing commitment: %w", err)
+	}
+	return commitment, nil
+}
+
+// Nullifier returns the nullifier of the commitment and the voter secret
+// provided.
+func Nullifier(commitment *big.Int, secret Secret) (*big.Int, error) {
+	if commitment == nil {
+		return nil, fmt.Errorf("nil commitment")
+	}
+	nullifier, err := poseidon.Hash([]*big.Int{commitment, secret.FF()})
+	if err != nil {
+		return nil, fmt.Errorf("error hashing nullifier: %w", err)
+	}
+	return nullifier, nil
+}
+
+// Derive returns both the commitment and the nullifier of the voter address,
+// the process ID and the voter secret provided.
+func Derive(address Address, processID ProcessID, secret Secret) (*big.Int, *big.Int, error) {
+	commitment, err := Commitment(address, processID, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	nullifier, err := Nullifier(commitment, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commitment, nullifier, nil
+}
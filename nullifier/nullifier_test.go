@@ -0,0 +1,48 @@
+package nullifier
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+func TestDerive(t *testing.T) {
+	// values of the VocdoniZ circuit inputs with zero address, process ID and
+	// secret
+	commitment, nullifier, err := Derive(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Error deriving nullifier: %v\n", err)
+	}
+	if commitment.String() != "5317387130258456662214331362918410991734007599705406860481038345552731150762" {
+		t.Errorf("Unexpected commitment: %s\n", commitment)
+	}
+	if nullifier.String() != "5630210813752003343528524591438274147462060549360432321983578688556994400044" {
+		t.Errorf("Unexpected nullifier: %s\n", nullifier)
+	}
+	// the values are reduced to the circuit field before hashing
+	address := Address{0x6d, 0xb9, 0x89}
+	processID := ProcessID(new(big.Int).Add(constants.Q, big.NewInt(2)).Bytes())
+	secret := Secret("super-secret-mnemonic-phrase")
+	commitment, nullifier, err = Derive(address, processID, secret)
+	if err != nil {
+		t.Fatalf("Error deriving nullifier: %v\n", err)
+	}
+	expected, _ := poseidon.Hash([]*big.Int{new(big.Int).SetBytes(address), big.NewInt(2), secret.FF()})
+	if commitment.Cmp(expected) != 0 {
+		t.Errorf("Unexpected commitment: expected %s, got %s\n", expected, commitment)
+	}
+	expected, _ = poseidon.Hash([]*big.Int{commitment, secret.FF()})
+	if nullifier.Cmp(expected) != 0 {
+		t.Errorf("Unexpected nullifier: expected %s, got %s\n", expected, nullifier)
+	}
+	// a different process leads to a different nullifier
+	_, other, err := Derive(address, ProcessID{0x01}, secret)
+	if err != nil {
+		t.Fatalf("Error deriving nullifier: %v\n", err)
+	}
+	if other.Cmp(nullifier) == 0 {
+		t.Error("Expected different nullifiers for different processes")
+	}
+}
@@ -0,0 +1,120 @@
+package nullifier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// UnlimitedOverwrites allows a voter to overwrite its vote any number of
+// times when used as Policy.MaxOverwrites.
+const UnlimitedOverwrites = -1
+
+// ErrDoubleVote is returned when a nullifier is registered again and the
+// policy of the registry does not allow to overwrite its vote.
+var ErrDoubleVote = errors.New("double vote")
+
+// Policy defines how the registry handles the votes of a nullifier already
+// registered. MaxOverwrites is the number of times that a vote can be
+// replaced, zero rejects every double vote and UnlimitedOverwrites accepts
+// all of them.
+type Policy struct {
+	MaxOverwrites int
+}
+
+// entry holds the current ballot of a nullifier and the number of times it
+// has been overwritten.
+type entry struct {
+	ballot     *big.Int
+	overwrites int
+}
+
+// Registry holds the ballots received by nullifier, detecting double votes
+// according to its policy. It is safe for concurrent use.
+type Registry struct {
+	policy  Policy
+	mtx     sync.RWMutex
+	entries map[string]*entry
+}
+
+// NewRegistry creates a new empty registry with the policy provided.
+func NewRegistry(policy Policy) (*Registry, error) {
+	if policy.MaxOverwrites < UnlimitedOverwrites {
+		return nil, fmt.Errorf("invalid max overwrites %d", policy.MaxOverwrites)
+	}
+	return &Registry{
+		policy:  policy,
+		entries: make(map[string]*entry),
+	}, nil
+}
+
+// Register stores the ballot provided for the nullifier. If the nullifier is
+// already registered and the policy allows it, the ballot replaces the
+// previous one, which is returned to let the caller undo it (for example,
+// from an encrypted tally). Otherwise it returns ErrDoubleVote.
+func (r *Registry) Register(nullifier, ballot *big.Int) (*big.Int, error) {
+	if nullifier == nil || nullifier.Sign() < 0 {
+		return nil, fmt.Errorf("invalid nullifier")
+	}
+	if ballot == nil {
+		return nil, fmt.Errorf("nil ballot")
+	}
+	key := nullifier.String()
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	current, ok := r.entries[key]
+	if !ok {
+		r.entries[key] = &entry{ballot: new(big.Int).Set(ballot)}
+		return nil, nil
+	}
+	if r.policy.MaxOverwrites != UnlimitedOverwrites && current.overwrites >= r.policy.MaxOverwrites {
+		return nil, fmt.Errorf("%w: nullifier %s already voted %d times", ErrDoubleVote, key, current.overwrites+1)
+	}
+	previous := current.ballot
+	current.ballot = new(big.Int).Set(ballot)
+	current.overwrites++
+	return previous, nil
+}
+
+// Ballot returns a copy of the current ballot of the nullifier provided, and
+// if it is registered.
+func (r *Registry) Ballot(nullifier *big.Int) (*big.Int, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	current, ok := r.entries[nullifier.String()]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).Set(current.ballot), true
+}
+
+// Overwrites returns the number of times that the vote of the nullifier
+// provided has been overwritten.
+func (r *Registry) Overwrites(nullifier *big.Int) int {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	if current, ok := r.entries[nullifier.String()]; ok {
+		return current.overwrites
+	}
+	return 0
+}
+
+// Len returns the number of nullifiers registered, that is, the number of
+// voters.
+func (r *Registry) Len() int {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return len(r.entries)
+}
+
+// Ballots returns a copy of the current ballot of every nullifier registered.
+func (r *Registry) Ballots() []*big.Int {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	ballots := make([]*big.Int, 0, len(r.entries))
+	for _, current := range r.entries {
+		ballots = append(ballots, new(big.Int).Set(current.ballot))
+	}
+	return ballots
+}
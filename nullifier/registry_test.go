@@ -0,0 +1,53 @@
+package nullifier
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	nullifier := big.NewInt(1234)
+	for _, tc := range []struct {
+		name     string
+		policy   Policy
+		accepted int
+	}{
+		{"no overwrites", Policy{}, 1},
+		{"two overwrites", Policy{MaxOverwrites: 2}, 3},
+		{"unlimited overwrites", Policy{MaxOverwrites: UnlimitedOverwrites}, 5},
+	} {
+		registry, err := NewRegistry(tc.policy)
+		if err != nil {
+			t.Fatalf("[%s] Error creating registry: %v\n", tc.name, err)
+		}
+		accepted := 0
+		for i := 1; i <= 5; i++ {
+			previous, err := registry.Register(nullifier, big.NewInt(int64(i)))
+			if err != nil {
+				if !errors.Is(err, ErrDoubleVote) {
+					t.Errorf("[%s] Unexpected error: %v\n", tc.name, err)
+				}
+				continue
+			}
+			// the previous ballot is returned on overwrites
+			if accepted > 0 && (previous == nil || previous.Int64() != int64(i-1)) {
+				t.Errorf("[%s] Unexpected previous ballot: %v\n", tc.name, previous)
+			}
+			accepted++
+		}
+		if accepted != tc.accepted {
+			t.Errorf("[%s] Expected %d accepted votes, got %d\n", tc.name, tc.accepted, accepted)
+		}
+		ballot, ok := registry.Ballot(nullifier)
+		if !ok || ballot.Int64() != int64(tc.accepted) {
+			t.Errorf("[%s] Unexpected ballot: %v\n", tc.name, ballot)
+		}
+		if registry.Len() != 1 || registry.Overwrites(nullifier) != tc.accepted-1 {
+			t.Errorf("[%s] Unexpected registry state\n", tc.name)
+		}
+	}
+	if _, err := NewRegistry(Policy{MaxOverwrites: -2}); err == nil {
+		t.Error("Expected error creating registry with invalid policy")
+	}
+}
@@ -0,0 +1,284 @@
+// Package paillier implements the Paillier cryptosystem with the Damgård–Jurik
+// generalization, where the plaintext space is Z_{n^s} and ciphertexts live in
+// Z*_{n^(s+1)}. Ciphertexts are compatible with the ones produced by
+// github.com/niclabs/tcpaillier for the same n, s and randomness.
+package paillier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+var one = big.NewInt(1)
+
+// PublicKey represents a Damgård–Jurik public key, defined by the modulus n
+// and the exponent s. It contains the precomputed values n+1, n^s and
+// n^(s+1).
+type PublicKey struct {
+	N           *big.Int
+	S           uint8
+	nPlusOne    *big.Int
+	nToS        *big.Int
+	nToSPlusOne *big.Int
+}
+
+// PrivateKey represents a Damgård–Jurik private key. Lambda is
+// lcm(p-1, q-1) and Mu is the inverse of Lambda mod n^s.
+type PrivateKey struct {
+	PublicKey
+	P, Q   *big.Int
+	Lambda *big.Int
+	Mu     *big.Int
+}
+
+// NewPublicKey returns the public key for the modulus n and the exponent s
+// provided.
+func NewPublicKey(n *big.Int, s uint8) (*PublicKey, error) {
+	if n == nil || n.Cmp(big.NewInt(2)) <= 0 || n.Bit(0) == 0 {
+		return nil, fmt.Errorf("n must be an odd number greater than 2")
+	}
+	if s < 1 {
+		return nil, fmt.Errorf("s should be at least 1, but it is %d", s)
+	}
+	bigS := big.NewInt(int64(s))
+	return &PublicKey{
+		N:           new(big.Int).Set(n),
+		S:           s,
+		nPlusOne:    new(big.Int).Add(n, one),
+		nToS:        new(big.Int).Exp(n, bigS, nil),
+		nToSPlusOne: new(big.Int).Exp(n, new(big.Int).Add(bigS, one), nil),
+	}, nil
+}
+
+// GenerateKey generates a new private key with a modulus of bitSize bits and
+// the exponent s provided.
+func GenerateKey(bitSize int, s uint8) (*PrivateKey, error) {
+	if bitSize < 16 {
+		return nil, fmt.Errorf("bitSize should be at least 16 bits, but it is %d", bitSize)
+	}
+	pSize := (bitSize + 1) / 2
+	qSize := bitSize - pSize
+	for {
+		p, err := rand.Prime(rand.Reader, pSize)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, qSize)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		sk, err := NewPrivateKey(p, q, s)
+		if err != nil {
+			// gcd(n, (p-1)(q-1)) != 1, try again
+			continue
+		}
+		return sk, nil
+	}
+}
+
+// NewPrivateKey returns the private key for the primes p and q and the
+// exponent s provided. It does not check that p and q are primes, but it
+// checks that gcd(pq, (p-1)(q-1)) = 1.
+func NewPrivateKey(p, q *big.Int, s uint8) (*PrivateKey, error) {
+	if p == nil || q == nil || p.Cmp(q) == 0 {
+		return nil, fmt.Errorf("p and q must be different primes")
+	}
+	pk, err := NewPublicKey(new(big.Int).Mul(p, q), s)
+	if err != nil {
+		return nil, err
+	}
+	pMinusOne := new(big.Int).Sub(p, one)
+	qMinusOne := new(big.Int).Sub(q, one)
+	phi := new(big.Int).Mul(pMinusOne, qMinusOne)
+	if new(big.Int).GCD(nil, nil, pk.N, phi).Cmp(one) != 0 {
+		return nil, fmt.Errorf("n and (p-1)(q-1) must be coprime")
+	}
+	// lambda = lcm(p-1, q-1) = (p-1)(q-1) / gcd(p-1, q-1)
+	lambda := new(big.Int).Div(phi, new(big.Int).GCD(nil, nil, pMinusOne, qMinusOne))
+	mu := new(big.Int).ModInverse(lambda, pk.nToS)
+	if mu == nil {
+		return nil, fmt.Errorf("lambda has no inverse mod n^s")
+	}
+	return &PrivateKey{
+		PublicKey: *pk,
+		P:         new(big.Int).Set(p),
+		Q:         new(big.Int).Set(q),
+		Lambda:    lambda,
+		Mu:        mu,
+	}, nil
+}
+
+// NPlusOne returns n+1, the generator g of the key.
+func (pk *PublicKey) NPlusOne() *big.Int {
+	return new(big.Int).Set(pk.nPlusOne)
+}
+
+// NToS returns n^s, the size of the plaintext space.
+func (pk *PublicKey) NToS() *big.Int {
+	return new(big.Int).Set(pk.nToS)
+}
+
+// NToSPlusOne returns n^(s+1), the modulus of the ciphertexts.
+func (pk *PublicKey) NToSPlusOne() *big.Int {
+	return new(big.Int).Set(pk.nToSPlusOne)
+}
+
+// EncryptRaw is a basic implementation of the Paillier encryption algorithm
+// over the precomputed values of a public key. It receives n+1, n^s, n^(s+1),
+// the message to encrypt and a random number r, and returns the resulting
+// ciphertext (n+1)^m * r^(n^s) mod n^(s+1). It does not validate its inputs,
+// use PublicKey.EncryptWithRandomness instead when the key is available.
+func EncryptRaw(nPlusOne, nToS, nToSPlusOne, msg, r *big.Int) *big.Int {
+	// msg mod n^s+1
+	m := new(big.Int).Mod(msg, nToSPlusOne)
+	// g^m mod n^s+1
+	nPlusOneToM := new(big.Int).Exp(nPlusOne, m, nToSPlusOne)
+	// g^m * r^n^s mod n^s+1
+	rToNToS := new(big.Int).Exp(r, nToS, nToSPlusOne)
+	c := new(big.Int).Mul(nPlusOneToM, rToNToS)
+	return c.Mod(c, nToSPlusOne)
+}
+
+// Encrypt encrypts the message provided with a random r and returns the
+// ciphertext and r.
+func (pk *PublicKey) Encrypt(msg *big.Int) (c, r *big.Int, err error) {
+	if r, err = pk.RandomR(); err != nil {
+		return nil, nil, err
+	}
+	if c, err = pk.EncryptWithRandomness(msg, r); err != nil {
+		return nil, nil, err
+	}
+	return c, r, nil
+}
+
+// EncryptWithRandomness encrypts the message provided with the random r
+// provided. The message must be in [0, n^s) and r must be in [1, n^(s+1)) and
+// coprime with n.
+func (pk *PublicKey) EncryptWithRandomness(msg, r *big.Int) (*big.Int, error) {
+	if msg == nil || msg.Sign() < 0 || msg.Cmp(pk.nToS) >= 0 {
+		return nil, fmt.Errorf("message must be between 0 (inclusive) and n^s (exclusive)")
+	}
+	if err := pk.checkUnit(r); err != nil {
+		return nil, fmt.Errorf("invalid r: %w", err)
+	}
+	return EncryptRaw(pk.nPlusOne, pk.nToS, pk.nToSPlusOne, msg, r), nil
+}
+
+// Add returns the ciphertext of the sum of the plaintexts of the ciphertexts
+// provided, that is its product mod n^(s+1).
+func (pk *PublicKey) Add(ciphertexts ...*big.Int) (*big.Int, error) {
+	if len(ciphertexts) == 0 {
+		return nil, fmt.Errorf("empty ciphertext list")
+	}
+	sum := big.NewInt(1)
+	for i, c := range ciphertexts {
+		if err := pk.checkUnit(c); err != nil {
+			return nil, fmt.Errorf("invalid ciphertext %d: %w", i, err)
+		}
+		sum.Mul(sum, c).Mod(sum, pk.nToSPlusOne)
+	}
+	return sum, nil
+}
+
+// ScalarMul returns the ciphertext of the plaintext of the ciphertext provided
+// multiplied by k mod n^s, that is c^(k mod n^s) mod n^(s+1).
+func (pk *PublicKey) ScalarMul(c, k *big.Int) (*big.Int, error) {
+	if err := pk.checkUnit(c); err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	if k == nil {
+		return nil, fmt.Errorf("nil scalar")
+	}
+	kModNToS := new(big.Int).Mod(k, pk.nToS)
+	return new(big.Int).Exp(c, kModNToS, pk.nToSPlusOne), nil
+}
+
+// Rerandomize returns a new ciphertext of the same plaintext of the ciphertext
+// provided, multiplying it by an encryption of zero with a random r. It also
+// returns r.
+func (pk *PublicKey) Rerandomize(c *big.Int) (*big.Int, *big.Int, error) {
+	zero, r, err := pk.Encrypt(big.NewInt(0))
+	if err != nil {
+		return nil, nil, err
+	}
+	rerandomized, err := pk.Add(c, zero)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rerandomized, r, nil
+}
+
+// RandomR returns a random number in [1, n) coprime with n, to be used as the
+// randomness of an encryption.
+func (pk *PublicKey) RandomR() (*big.Int, error) {
+	for {
+		r, err := rand.Int(rand.Reader, pk.N)
+		if err != nil {
+			return nil, err
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, pk.N).Cmp(one) == 0 {
+			return r, nil
+		}
+	}
+}
+
+// Decrypt returns the plaintext of the ciphertext provided. It computes
+// c^lambda mod n^(s+1) = (n+1)^(m*lambda mod n^s), gets m*lambda with the
+// Damgård–Jurik discrete logarithm algorithm and multiplies it by mu.
+func (sk *PrivateKey) Decrypt(c *big.Int) (*big.Int, error) {
+	if err := sk.checkUnit(c); err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	a := new(big.Int).Exp(c, sk.Lambda, sk.nToSPlusOne)
+	mLambda := sk.dlog(a)
+	m := new(big.Int).Mul(mLambda, sk.Mu)
+	return m.Mod(m, sk.nToS), nil
+}
+
+// dlog returns i in [0, n^s) such that (n+1)^i = a mod n^(s+1). It is the
+// recursive algorithm described in the Damgård–Jurik paper, that extracts i
+// mod n^j from L(a mod n^(j+1)) for j = 1..s, where L(x) = (x-1)/n.
+func (pk *PublicKey) dlog(a *big.Int) *big.Int {
+	n := pk.N
+	i := big.NewInt(0)
+	nToJ := new(big.Int).Set(n)           // n^j
+	nToJPlusOne := new(big.Int).Mul(n, n) // n^(j+1)
+	for j := 1; j <= int(pk.S); j++ {
+		// t1 = L(a mod n^(j+1))
+		t1 := new(big.Int).Mod(a, nToJPlusOne)
+		t1.Sub(t1, one).Div(t1, n)
+		t2 := new(big.Int).Set(i)
+		nToKMinusOne := big.NewInt(1) // n^(k-1)
+		kFactorial := big.NewInt(1)   // k!
+		for k := 2; k <= j; k++ {
+			bigK := big.NewInt(int64(k))
+			i.Sub(i, one)
+			t2.Mul(t2, i).Mod(t2, nToJ)
+			nToKMinusOne.Mul(nToKMinusOne, n)
+			kFactorial.Mul(kFactorial, bigK)
+			// t1 = t1 - t2 * n^(k-1) / k! mod n^j
+			term := new(big.Int).Mul(t2, nToKMinusOne)
+			term.Mul(term, new(big.Int).ModInverse(kFactorial, nToJ))
+			t1.Sub(t1, term).Mod(t1, nToJ)
+		}
+		i = t1
+		nToJ.Mul(nToJ, n)
+		nToJPlusOne.Mul(nToJPlusOne, n)
+	}
+	return i
+}
+
+// checkUnit checks that x is in [1, n^(s+1)) and coprime with n.
+func (pk *PublicKey) checkUnit(x *big.Int) error {
+	if x == nil || x.Sign() <= 0 || x.Cmp(pk.nToSPlusOne) >= 0 {
+		return fmt.Errorf("must be between 1 (inclusive) and n^(s+1) (exclusive)")
+	}
+	if new(big.Int).GCD(nil, nil, x, pk.N).Cmp(one) != 0 {
+		return fmt.Errorf("must be coprime with n")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package paillier
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+// newTCPaillierKey generates a tcpaillier key and a PrivateKey with the same
+// primes, so both implementations can be compared.
+func newTCPaillierKey(t *testing.T, bitSize int, s uint8) ([]*tcpaillier.KeyShare, *tcpaillier.PubKey, *PrivateKey) {
+	t.Helper()
+	p, p1, err := tcpaillier.GenerateSafePrimes(bitSize / 2)
+	if err != nil {
+		t.Fatalf("Error generating primes: %v\n", err)
+	}
+	var q, q1 *big.Int
+	for {
+		if q, q1, err = tcpaillier.GenerateSafePrimes(bitSize / 2); err != nil {
+			t.Fatalf("Error generating primes: %v\n", err)
+		}
+		if p.Cmp(q) != 0 && p.Cmp(q1) != 0 && q.Cmp(p1) != 0 {
+			break
+		}
+	}
+	shares, pk, err := tcpaillier.NewFixedKey(bitSize, s, 5, 3, &tcpaillier.FixedParams{P: p, P1: p1, Q: q, Q1: q1})
+	if err != nil {
+		t.Fatalf("Error generating tcpaillier key: %v\n", err)
+	}
+	sk, err := NewPrivateKey(p, q, s)
+	if err != nil {
+		t.Fatalf("Error creating private key: %v\n", err)
+	}
+	return shares, pk, sk
+}
+
+func TestEncryptMatchesTCPaillier(t *testing.T) {
+	for _, s := range []uint8{1, 2, 3} {
+		_, tcpk, sk := newTCPaillierKey(t, 128, s)
+		msg := big.NewInt(1234567890)
+		c, r, err := sk.Encrypt(msg)
+		if err != nil {
+			t.Fatalf("Error encrypting: %v\n", err)
+		}
+		expected, err := tcpk.EncryptFixed(msg, r)
+		if err != nil {
+			t.Fatalf("Error encrypting with tcpaillier: %v\n", err)
+		}
+		if c.Cmp(expected) != 0 {
+			t.Errorf("Ciphertexts are different with s = %d\n", s)
+		}
+		// decrypt a tcpaillier ciphertext with the private key
+		tcC, _, err := tcpk.Encrypt(msg)
+		if err != nil {
+			t.Fatalf("Error encrypting with tcpaillier: %v\n", err)
+		}
+		dec, err := sk.Decrypt(tcC)
+		if err != nil {
+			t.Fatalf("Error decrypting: %v\n", err)
+		}
+		if dec.Cmp(msg) != 0 {
+			t.Errorf("Unexpected plaintext with s = %d: expected %s, got %s\n", s, msg, dec)
+		}
+	}
+}
+
+func TestDecryptWithTCPaillierShares(t *testing.T) {
+	shares, tcpk, sk := newTCPaillierKey(t, 128, 1)
+	msg := big.NewInt(987654321)
+	c, _, err := sk.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	decryptionShares := make([]*tcpaillier.DecryptionShare, tcpk.K)
+	for i := range decryptionShares {
+		if decryptionShares[i], err = shares[i].PartialDecrypt(c); err != nil {
+			t.Fatalf("Error decrypting share %d: %v\n", i+1, err)
+		}
+	}
+	dec, err := tcpk.CombineShares(decryptionShares...)
+	if err != nil {
+		t.Fatalf("Error combining shares: %v\n", err)
+	}
+	if dec.Cmp(msg) != 0 {
+		t.Errorf("Unexpected plaintext: expected %s, got %s\n", msg, dec)
+	}
+}
+
+func TestHomomorphicOperations(t *testing.T) {
+	for _, s := range []uint8{1, 2, 3} {
+		sk, err := GenerateKey(128, s)
+		if err != nil {
+			t.Fatalf("Error generating key: %v\n", err)
+		}
+		// use plaintexts greater than n when s > 1
+		a := new(big.Int).Add(sk.N, big.NewInt(int64(s)))
+		a.Mod(a, sk.NToS())
+		b := big.NewInt(42)
+		ca, _, err := sk.Encrypt(a)
+		if err != nil {
+			t.Fatalf("Error encrypting: %v\n", err)
+		}
+		cb, _, err := sk.Encrypt(b)
+		if err != nil {
+			t.Fatalf("Error encrypting: %v\n", err)
+		}
+		// a + b
+		sum, err := sk.Add(ca, cb)
+		if err != nil {
+			t.Fatalf("Error adding: %v\n", err)
+		}
+		expected := new(big.Int).Add(a, b)
+		if dec, _ := sk.Decrypt(sum); dec.Cmp(expected.Mod(expected, sk.NToS())) != 0 {
+			t.Errorf("Unexpected sum with s = %d: expected %s, got %s\n", s, expected, dec)
+		}
+		// a * 7
+		mul, err := sk.ScalarMul(ca, big.NewInt(7))
+		if err != nil {
+			t.Fatalf("Error multiplying: %v\n", err)
+		}
+		expected = new(big.Int).Mul(a, big.NewInt(7))
+		if dec, _ := sk.Decrypt(mul); dec.Cmp(expected.Mod(expected, sk.NToS())) != 0 {
+			t.Errorf("Unexpected product with s = %d: expected %s, got %s\n", s, expected, dec)
+		}
+		// rerandomized b
+		reRand, _, err := sk.Rerandomize(cb)
+		if err != nil {
+			t.Fatalf("Error rerandomizing: %v\n", err)
+		}
+		if reRand.Cmp(cb) == 0 {
+			t.Errorf("Rerandomized ciphertext is equal to the original with s = %d\n", s)
+		}
+		if dec, _ := sk.Decrypt(reRand); dec.Cmp(b) != 0 {
+			t.Errorf("Unexpected rerandomized plaintext with s = %d: expected %s, got %s\n", s, b, dec)
+		}
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	sk, err := GenerateKey(64, 1)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	if _, err := sk.EncryptWithRandomness(sk.NToS(), big.NewInt(2)); err == nil {
+		t.Error("Expected error encrypting a message out of range")
+	}
+	if _, err := sk.EncryptWithRandomness(big.NewInt(1), sk.P); err == nil {
+		t.Error("Expected error encrypting with r not coprime with n")
+	}
+	if _, err := sk.Decrypt(sk.NToSPlusOne()); err == nil {
+		t.Error("Expected error decrypting a ciphertext out of range")
+	}
+	if _, err := sk.Add(); err == nil {
+		t.Error("Expected error adding an empty list")
+	}
+}
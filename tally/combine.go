@@ -0,0 +1,134 @@
+package tally
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/internal/subset"
+)
+
+// CombineRobust combines the decryption shares provided to get the plaintext
+// of their ciphertext, tolerating bad shares. Repeated shares of a trustee are
+// skipped, keeping the first one, and a share with an index out of range is
+// rejected. The subsets of k shares are tried in order until one combines into
+// c^(4*delta^2*d) = 1 mod n, which a corrupted share breaks with overwhelming
+// probability. With more than k shares, the plaintext is cross-checked with
+// another subset, disjoint from the first one if there are at least 2k shares,
+// which also catches a share that shifts the plaintext without breaking the
+// combination, since its Lagrange coefficient changes with the subset.
+func CombineRobust(pk *tcpaillier.PubKey, shares ...*tcpaillier.DecryptionShare) (*big.Int, error) {
+	if pk == nil || pk.N == nil {
+		return nil, fmt.Errorf("public key is required")
+	}
+	unique := make([]*tcpaillier.DecryptionShare, 0, len(shares))
+	seen := make(map[uint8]bool, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("nil decryption share %d", i)
+		}
+		if share.Index < 1 || share.Index > pk.L {
+			return nil, fmt.Errorf("decryption share %d: index must be between 1 and %d, got %d", i, pk.L, share.Index)
+		}
+		if seen[share.Index] {
+			continue
+		}
+		seen[share.Index] = true
+		// a share that is not a unit can not be combined, it is bad anyway
+		if checkUnit(pk, share.Ci) != nil {
+			continue
+		}
+		unique = append(unique, share)
+	}
+	k := int(pk.K)
+	if len(unique) < k {
+		return nil, fmt.Errorf("needed %d valid decryption shares, got %d", k, len(unique))
+	}
+	plaintext, used, err := combineAnySubset(pk, unique, nil)
+	if err != nil || len(unique) == k {
+		return plaintext, err
+	}
+	// try first the subsets of the shares not used, completed with the used
+	// ones if there are less than 2k shares
+	reordered := make([]*tcpaillier.DecryptionShare, 0, len(unique))
+	for _, share := range unique {
+		if !used[share.Index] {
+			reordered = append(reordered, share)
+		}
+	}
+	for _, share := range unique {
+		if used[share.Index] {
+			reordered = append(reordered, share)
+		}
+	}
+	// the other subsets may have too many bad shares to combine, then there
+	// is nothing to cross-check with
+	check, _, err := combineAnySubset(pk, reordered, used)
+	if err != nil {
+		return plaintext, nil
+	}
+	if check.Cmp(plaintext) != 0 {
+		return nil, fmt.Errorf("subsets of decryption shares decrypt to different plaintexts")
+	}
+	return plaintext, nil
+}
+
+// combineAnySubset tries the subsets of k shares in order, but the one with
+// exactly the indices to exclude, and returns the plaintext of the first one
+// that combines with the indices of its shares.
+func combineAnySubset(pk *tcpaillier.PubKey, shares []*tcpaillier.DecryptionShare,
+	exclude map[uint8]bool,
+) (*big.Int, map[uint8]bool, error) {
+	k := int(pk.K)
+	if len(shares) < k {
+		return nil, nil, fmt.Errorf("needed %d decryption shares, got %d", k, len(shares))
+	}
+	positions := subset.First(k)
+	selected := make([]*tcpaillier.DecryptionShare, k)
+	for tries := 0; tries < subset.MaxTries; tries++ {
+		excluded := len(exclude) == k
+		for i, j := range positions {
+			selected[i] = shares[j]
+			excluded = excluded && exclude[shares[j].Index]
+		}
+		if !excluded {
+			if plaintext, err := combineSubset(pk, selected); err == nil {
+				used := make(map[uint8]bool, k)
+				for _, share := range selected {
+					used[share.Index] = true
+				}
+				return plaintext, used, nil
+			}
+		}
+		if !subset.Next(positions, len(shares)) {
+			return nil, nil, fmt.Errorf("no subset of %d decryption shares combines", k)
+		}
+	}
+	return nil, nil, fmt.Errorf("no valid subset of %d decryption shares in the first %d tried", k, subset.MaxTries)
+}
+
+// combineSubset combines exactly k shares with distinct indices as
+// pk.CombineShares does, but fails if the combination c' is not 1 mod n
+// instead of returning a wrong plaintext.
+func combineSubset(pk *tcpaillier.PubKey, shares []*tcpaillier.DecryptionShare) (*big.Int, error) {
+	nToSPlusOne := pk.Cache().NToSPlusOne
+	cPrime := big.NewInt(1)
+	for _, share := range shares {
+		// 2 * delta * lambda_i, which is an integer
+		num := new(big.Int).Lsh(pk.Delta, 1)
+		den := big.NewInt(1)
+		for _, other := range shares {
+			if other.Index != share.Index {
+				num.Mul(num, big.NewInt(int64(other.Index)))
+				den.Mul(den, big.NewInt(int64(other.Index)-int64(share.Index)))
+			}
+		}
+		lambda2 := num.Quo(num, den)
+		cPrime.Mul(cPrime, new(big.Int).Exp(share.Ci, lambda2, nToSPlusOne)).Mod(cPrime, nToSPlusOne)
+	}
+	l, rem := new(big.Int).QuoRem(cPrime.Sub(cPrime, big.NewInt(1)), pk.N, new(big.Int))
+	if rem.Sign() != 0 {
+		return nil, fmt.Errorf("invalid combination of decryption shares")
+	}
+	return l.Mul(l, pk.Constant).Mod(l, pk.N), nil
+}
@@ -0,0 +1,65 @@
+package tally
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+func TestCombineRobust(t *testing.T) {
+	keyShares, pk, err := tcpaillier.NewKey(128, 1, 7, 4)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	c, _, err := pk.Encrypt(big.NewInt(42))
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	decryptShares := func() []*tcpaillier.DecryptionShare {
+		shares := make([]*tcpaillier.DecryptionShare, len(keyShares))
+		for i, keyShare := range keyShares {
+			if shares[i], err = keyShare.PartialDecrypt(c); err != nil {
+				t.Fatalf("Error decrypting share %d: %v\n", i+1, err)
+			}
+		}
+		return shares
+	}
+	checkPlaintext := func(name string, shares ...*tcpaillier.DecryptionShare) {
+		plaintext, err := CombineRobust(pk, shares...)
+		if err != nil {
+			t.Fatalf("Error combining shares %s: %v\n", name, err)
+		}
+		if plaintext.Int64() != 42 {
+			t.Errorf("Expected 42 combining shares %s, got %s\n", name, plaintext)
+		}
+	}
+	shares := decryptShares()
+	checkPlaintext("with repeated indices", shares[4], shares[4], shares[0], shares[6], shares[0], shares[2])
+	// the subsets with the corrupted shares of trustees 1 and 3 are skipped
+	nToSPlusOne := pk.Cache().NToSPlusOne
+	shares[0].Ci.Mul(shares[0].Ci, big.NewInt(2)).Mod(shares[0].Ci, nToSPlusOne)
+	shares[2].Ci = big.NewInt(1)
+	checkPlaintext("with corrupted shares", shares...)
+	checkPlaintext("with k valid shares", shares[:6]...)
+	if _, err := CombineRobust(pk, shares[:5]...); err == nil {
+		t.Error("Expected error combining less than k valid shares")
+	}
+	// a share multiplied by 1 + n shifts the plaintext, only the cross-check
+	// with another subset can catch it
+	shares = decryptShares()
+	shares[0].Ci.Mul(shares[0].Ci, new(big.Int).Add(pk.N, big.NewInt(1))).Mod(shares[0].Ci, nToSPlusOne)
+	if _, err := CombineRobust(pk, shares...); err == nil {
+		t.Error("Expected error combining shares that decrypt to different plaintexts")
+	}
+	// invalid indices are errors instead of panics
+	for _, share := range []*tcpaillier.DecryptionShare{
+		nil,
+		{Index: 0, Ci: shares[1].Ci},
+		{Index: 8, Ci: shares[1].Ci},
+	} {
+		if _, err := CombineRobust(pk, shares[1], shares[2], shares[3], shares[4], share); err == nil {
+			t.Errorf("Expected error combining share %v\n", share)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package tally aggregates encrypted ballots using the homomorphic property of
+// the Paillier cryptosystem and decodes the decrypted result into the total of
+// every ballot field.
+package tally
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/circom"
+)
+
+// Tally holds the encrypted sum of the ballots received under a Paillier
+// public key. The sum is kept mod n^(s+1) and starts as the encryption of zero
+// with r = 1 (which is 1). It is safe for concurrent use.
+type Tally struct {
+	pk     *tcpaillier.PubKey
+	config circom.BallotConfig
+	mtx    sync.Mutex
+	sum    *big.Int
+	count  int
+}
+
+// NewTally creates a new empty tally for the public key and ballot
+// configuration provided.
+func NewTally(pk *tcpaillier.PubKey, config circom.BallotConfig) (*Tally, error) {
+	if pk == nil || pk.N == nil {
+		return nil, fmt.Errorf("public key is required")
+	}
+	if config.MaxCount <= 0 {
+		return nil, fmt.Errorf("max count must be greater than 0, got %d", config.MaxCount)
+	}
+	if config.MaxValue <= 0 {
+		return nil, fmt.Errorf("max value must be greater than 0, got %d", config.MaxValue)
+	}
+	if config.Base <= 1 {
+		return nil, fmt.Errorf("base must be greater than 1, got %d", config.Base)
+	}
+	return &Tally{
+		pk:     pk,
+		config: config,
+		sum:    big.NewInt(1),
+	}, nil
+}
+
+// Add includes the ciphertext provided into the encrypted sum. The ciphertext
+// must be between 1 (inclusive) and n^(s+1) (exclusive).
+func (t *Tally) Add(ciphertext *big.Int) error {
+	if ciphertext == nil {
+		return fmt.Errorf("nil ciphertext")
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	// pk.Add checks the range of every ciphertext but the first one
+	sum, err := t.pk.Add(t.sum, ciphertext)
+	if err != nil {
+		return err
+	}
+	t.sum = sum
+	t.count++
+	return nil
+}
+
+// AddStream includes every ciphertext received through the channel provided
+// into the encrypted sum until the channel is closed. It stops at the first
+// invalid ciphertext and returns the error, the ciphertexts added before it
+// remain in the sum.
+func (t *Tally) AddStream(ciphertexts <-chan *big.Int) error {
+	for c := range ciphertexts {
+		if err := t.Add(c); err != nil {
+			return fmt.Errorf("ciphertext %d: %w", t.Count()+1, err)
+		}
+	}
+	return nil
+}
+
+// Sum returns a copy of the current encrypted sum.
+func (t *Tally) Sum() *big.Int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return new(big.Int).Set(t.sum)
+}
+
+// Count returns the number of ciphertexts included in the sum.
+func (t *Tally) Count() int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.count
+}
+
+// Snapshot is the encrypted sum of a tally at some point and the number of
+// ciphertexts included in it. Every trustee decrypts the same snapshot, and
+// the results are decoded with its count, even if more ballots are added
+// meanwhile.
+type Snapshot struct {
+	Sum   *big.Int
+	Count int
+}
+
+// Snapshot returns a copy of the current encrypted sum and the number of
+// ciphertexts included in it, read together so they match even if Add runs
+// concurrently.
+func (t *Tally) Snapshot() *Snapshot {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return &Snapshot{Sum: new(big.Int).Set(t.sum), Count: t.count}
+}
+
+// checkSnapshot checks that the snapshot provided has a valid ciphertext and
+// a count not greater than the ciphertexts added.
+func (t *Tally) checkSnapshot(snapshot *Snapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("snapshot is required")
+	}
+	if err := checkUnit(t.pk, snapshot.Sum); err != nil {
+		return fmt.Errorf("invalid snapshot sum: %w", err)
+	}
+	if count := t.Count(); snapshot.Count < 0 || snapshot.Count > count {
+		return fmt.Errorf("snapshot count must be between 0 and %d, got %d", count, snapshot.Count)
+	}
+	return nil
+}
+
+// PartialDecrypt computes the decryption share of the encrypted sum of the
+// snapshot provided using the key share provided.
+func (t *Tally) PartialDecrypt(share *tcpaillier.KeyShare, snapshot *Snapshot) (*tcpaillier.DecryptionShare, error) {
+	if share == nil {
+		return nil, fmt.Errorf("key share is required")
+	}
+	if err := t.checkSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	return share.PartialDecrypt(snapshot.Sum)
+}
+
+// PartialDecryptWithProof computes the decryption share of the encrypted sum
+// of the snapshot provided using the key share provided, with the proof that
+// it was computed correctly.
+func (t *Tally) PartialDecryptWithProof(share *tcpaillier.KeyShare, snapshot *Snapshot) (*VerifiedShare, error) {
+	if share == nil {
+		return nil, fmt.Errorf("key share is required")
+	}
+	if err := t.checkSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	ds, proof, err := share.PartialDecryptWithProof(snapshot.Sum)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifiedShare{Share: ds, Proof: proof}, nil
+}
+
+// Decrypt combines the decryption shares provided to get the plaintext of the
+// encrypted sum. At least k valid shares are required, see CombineRobust.
+func (t *Tally) Decrypt(shares ...*tcpaillier.DecryptionShare) (*big.Int, error) {
+	return CombineRobust(t.pk, shares...)
+}
+
+// Results combines the decryption shares of the snapshot provided and decodes
+// the plaintext into the total of every ballot field with the count of the
+// snapshot. It fails if the ballot configuration base is too small for that
+// count. The shares are not checked against the sum, see VerifiedResults.
+func (t *Tally) Results(snapshot *Snapshot, shares ...*tcpaillier.DecryptionShare) ([]*big.Int, error) {
+	if err := t.checkSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	plaintext, err := t.Decrypt(shares...)
+	if err != nil {
+		return nil, err
+	}
+	return circom.DecodeTally(plaintext, t.config, snapshot.Count)
+}
+
+// VerifiedResults verifies the decryption shares provided against the
+// encrypted sum of the snapshot provided, combines k valid ones and decodes
+// the plaintext into the total of every ballot field with the count of the
+// snapshot. It returns the indices of the trustees whose shares were invalid,
+// see CombineVerified.
+func (t *Tally) VerifiedResults(snapshot *Snapshot, shares ...*VerifiedShare) ([]*big.Int, []uint8, error) {
+	if err := t.checkSnapshot(snapshot); err != nil {
+		return nil, nil, err
+	}
+	plaintext, invalid, err := CombineVerified(t.pk, snapshot.Sum, shares...)
+	if err != nil {
+		return nil, invalid, err
+	}
+	results, err := circom.DecodeTally(plaintext, t.config, snapshot.Count)
+	if err != nil {
+		return nil, invalid, err
+	}
+	return results, invalid, nil
+}
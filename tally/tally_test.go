@@ -0,0 +1,99 @@
+package tally
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/circom"
+)
+
+func TestTally(t *testing.T) {
+	// set parameters
+	bitSize := 128
+	s := uint8(1)
+	l := uint8(5) // number of shares
+	k := uint8(3) // threshold
+	config := circom.BallotConfig{MaxCount: 4, MaxValue: 5, Base: 100}
+	ballots := [][]int{
+		{1, 0, 3, 2},
+		{4, 1, 0, 0},
+		{0, 2, 5, 1},
+	}
+	expected := []int64{5, 3, 8, 3}
+	// generate the key
+	shares, pk, err := tcpaillier.NewKey(bitSize, s, l, k)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	tally, err := NewTally(pk, config)
+	if err != nil {
+		t.Fatalf("Error creating tally: %v\n", err)
+	}
+	// encrypt the ballots and send them through the stream
+	stream := make(chan *big.Int)
+	go func() {
+		defer close(stream)
+		for _, ballot := range ballots {
+			c, _, err := pk.Encrypt(circom.EncodeBallot(ballot, config))
+			if err != nil {
+				t.Errorf("Error encrypting: %v\n", err)
+				return
+			}
+			stream <- c
+		}
+	}()
+	if err := tally.AddStream(stream); err != nil {
+		t.Fatalf("Error adding ciphertexts: %v\n", err)
+	}
+	if tally.Count() != len(ballots) {
+		t.Fatalf("Unexpected count: expected %d, got %d\n", len(ballots), tally.Count())
+	}
+	// partial decrypt with k shares and decode the results, with the count
+	// of the sum decrypted even if more ballots are added meanwhile
+	snapshot := tally.Snapshot()
+	decryptionShares := make([]*tcpaillier.DecryptionShare, k)
+	for i := range decryptionShares {
+		if decryptionShares[i], err = tally.PartialDecrypt(shares[i], snapshot); err != nil {
+			t.Fatalf("Error decrypting share %d: %v\n", i+1, err)
+		}
+	}
+	late, _, err := pk.Encrypt(circom.EncodeBallot(ballots[0], config))
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	if err := tally.Add(late); err != nil {
+		t.Fatalf("Error adding ciphertext: %v\n", err)
+	}
+	if _, err := tally.Results(&Snapshot{Sum: snapshot.Sum, Count: tally.Count() + 1}, decryptionShares...); err == nil {
+		t.Error("Expected error getting results with a count over the ciphertexts added")
+	}
+	results, err := tally.Results(snapshot, decryptionShares...)
+	if err != nil {
+		t.Fatalf("Error getting results: %v\n", err)
+	}
+	for i, result := range results {
+		if result.Int64() != expected[i] {
+			t.Errorf("Unexpected result for field %d: expected %d, got %s\n", i, expected[i], result)
+		}
+	}
+}
+
+func TestTallyInvalidCiphertext(t *testing.T) {
+	_, pk, err := tcpaillier.NewKey(64, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	tally, err := NewTally(pk, circom.BallotConfig{MaxCount: 1, MaxValue: 1, Base: 10})
+	if err != nil {
+		t.Fatalf("Error creating tally: %v\n", err)
+	}
+	for _, c := range []*big.Int{big.NewInt(0), pk.Cache().NToSPlusOne} {
+		if err := tally.Add(c); err == nil {
+			t.Errorf("Expected error adding ciphertext %s\n", c)
+		}
+	}
+	if tally.Count() != 0 {
+		t.Errorf("Unexpected count: expected 0, got %d\n", tally.Count())
+	}
+}
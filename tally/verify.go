@@ -0,0 +1,103 @@
+package tally
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/niclabs/tcpaillier"
+)
+
+// VerifiedShare is the decryption share of a trustee with the zero-knowledge
+// proof that it was computed with the key share that matches the
+// verification key of the trustee in the public key.
+type VerifiedShare struct {
+	Share *tcpaillier.DecryptionShare
+	Proof *tcpaillier.DecryptShareZK
+}
+
+// VerifyShare checks the proof of the decryption share of the ciphertext c
+// provided against the verification key of its trustee, pk.Vi[index-1]. The
+// verification keys in the proof are ignored, the ones of the public key are
+// used instead.
+func VerifyShare(pk *tcpaillier.PubKey, c *big.Int, vs *VerifiedShare) error {
+	if vs == nil || vs.Share == nil || vs.Share.Ci == nil {
+		return fmt.Errorf("missing decryption share")
+	}
+	if vs.Proof == nil || vs.Proof.Z == nil || vs.Proof.E == nil {
+		return fmt.Errorf("missing decryption share proof")
+	}
+	if vs.Share.Index < 1 || int(vs.Share.Index) > len(pk.Vi) {
+		return fmt.Errorf("invalid trustee index %d", vs.Share.Index)
+	}
+	if vs.Proof.Z.Sign() < 0 || vs.Proof.E.Sign() < 0 {
+		return fmt.Errorf("proof values must be positive")
+	}
+	// the proof inverts ci, so it must be a unit mod n^(s+1)
+	if err := checkUnit(pk, vs.Share.Ci); err != nil {
+		return fmt.Errorf("invalid decryption share: %w", err)
+	}
+	proof := &tcpaillier.DecryptShareZK{
+		V:  pk.V,
+		Vi: pk.Vi[vs.Share.Index-1],
+		Z:  vs.Proof.Z,
+		E:  vs.Proof.E,
+	}
+	if err := proof.Verify(pk, c, vs.Share); err != nil {
+		return fmt.Errorf("invalid proof of trustee %d: %w", vs.Share.Index, err)
+	}
+	return nil
+}
+
+// CombineVerified verifies every decryption share of the ciphertext c
+// provided and combines the valid ones with CombineRobust to get its
+// plaintext. The shares with an invalid proof are dropped and the indices of
+// their trustees returned, also when the combination fails because there are
+// less than k valid shares, including the indices out of range. Repeated
+// shares of a trustee are skipped.
+func CombineVerified(pk *tcpaillier.PubKey, c *big.Int, shares ...*VerifiedShare) (*big.Int, []uint8, error) {
+	if pk == nil || pk.N == nil {
+		return nil, nil, fmt.Errorf("public key is required")
+	}
+	if err := checkUnit(pk, c); err != nil {
+		return nil, nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	var invalid []uint8
+	valid := make([]*tcpaillier.DecryptionShare, 0, len(shares))
+	seen := make(map[uint8]bool, len(shares))
+	blamed := make(map[uint8]bool, len(shares))
+	for _, vs := range shares {
+		if err := VerifyShare(pk, c, vs); err != nil {
+			// a share without index can not be blamed on any trustee
+			if vs != nil && vs.Share != nil && !blamed[vs.Share.Index] {
+				blamed[vs.Share.Index] = true
+				invalid = append(invalid, vs.Share.Index)
+			}
+			continue
+		}
+		if seen[vs.Share.Index] {
+			continue
+		}
+		seen[vs.Share.Index] = true
+		valid = append(valid, vs.Share)
+	}
+	if len(valid) < int(pk.K) {
+		return nil, invalid, fmt.Errorf("needed %d valid decryption shares, got %d (invalid shares of trustees %v)",
+			pk.K, len(valid), invalid)
+	}
+	plaintext, err := CombineRobust(pk, valid...)
+	if err != nil {
+		return nil, invalid, err
+	}
+	return plaintext, invalid, nil
+}
+
+// checkUnit checks that x is between 1 and n^(s+1) and coprime with n.
+func checkUnit(pk *tcpaillier.PubKey, x *big.Int) error {
+	if x == nil || x.Sign() <= 0 || x.Cmp(pk.Cache().NToSPlusOne) >= 0 {
+		return fmt.Errorf("value must be between 1 and n^(s+1)")
+	}
+	if new(big.Int).GCD(nil, nil, x, pk.N).Cmp(big.NewInt(1)) != 0 {
+		return fmt.Errorf("value must be coprime with n")
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package tally
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/circom"
+)
+
+func TestCombineVerified(t *testing.T) {
+	shares, pk, err := tcpaillier.NewKey(128, 1, 5, 3)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	c, _, err := pk.Encrypt(big.NewInt(42))
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	verifiedShares := make([]*VerifiedShare, len(shares))
+	for i, share := range shares {
+		ds, proof, err := share.PartialDecryptWithProof(c)
+		if err != nil {
+			t.Fatalf("Error decrypting share %d: %v\n", i+1, err)
+		}
+		verifiedShares[i] = &VerifiedShare{Share: ds, Proof: proof}
+	}
+	// trustee 1 publishes a wrong share, trustee 2 a proof for another
+	// ciphertext
+	verifiedShares[0].Share.Ci.Mul(verifiedShares[0].Share.Ci, big.NewInt(2))
+	verifiedShares[0].Share.Ci.Mod(verifiedShares[0].Share.Ci, pk.Cache().NToSPlusOne)
+	other, _, err := pk.Encrypt(big.NewInt(7))
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	ds, proof, err := shares[1].PartialDecryptWithProof(other)
+	if err != nil {
+		t.Fatalf("Error decrypting share: %v\n", err)
+	}
+	verifiedShares[1] = &VerifiedShare{Share: ds, Proof: proof}
+	// the invalid shares are dropped, and the repeated one skipped
+	plaintext, invalid, err := CombineVerified(pk, c, append(verifiedShares, verifiedShares[2])...)
+	if err != nil {
+		t.Fatalf("Error combining shares: %v\n", err)
+	}
+	if plaintext.Int64() != 42 {
+		t.Errorf("Expected 42, got %s\n", plaintext)
+	}
+	if len(invalid) != 2 || invalid[0] != 1 || invalid[1] != 2 {
+		t.Errorf("Expected invalid trustees [1 2], got %v\n", invalid)
+	}
+	// a proof is checked against the verification key of its trustee in the
+	// public key, not the one in the proof
+	forged := *verifiedShares[4].Proof
+	forged.Vi = verifiedShares[3].Proof.Vi
+	if err := VerifyShare(pk, c, &VerifiedShare{Share: verifiedShares[4].Share, Proof: &forged}); err != nil {
+		t.Errorf("Error verifying share: %v\n", err)
+	}
+	if err := VerifyShare(pk, c, &VerifiedShare{Share: verifiedShares[3].Share, Proof: verifiedShares[4].Proof}); err == nil {
+		t.Error("Expected error verifying a proof of another trustee")
+	}
+	// less than k valid shares
+	_, invalid, err = CombineVerified(pk, c, verifiedShares[:4]...)
+	if err == nil {
+		t.Error("Expected error combining less than k valid shares")
+	}
+	if len(invalid) != 2 {
+		t.Errorf("Expected 2 invalid trustees, got %v\n", invalid)
+	}
+	// a share out of range is dropped and reported like any invalid one
+	outOfRange := &VerifiedShare{
+		Share: &tcpaillier.DecryptionShare{Index: 6, Ci: verifiedShares[2].Share.Ci},
+		Proof: verifiedShares[2].Proof,
+	}
+	plaintext, invalid, err = CombineVerified(pk, c, append(verifiedShares, outOfRange)...)
+	if err != nil {
+		t.Fatalf("Error combining shares: %v\n", err)
+	}
+	if plaintext.Int64() != 42 {
+		t.Errorf("Expected 42, got %s\n", plaintext)
+	}
+	if fmt.Sprint(invalid) != fmt.Sprint([]uint8{1, 2, 6}) {
+		t.Errorf("Expected invalid trustees [1 2 6], got %v\n", invalid)
+	}
+	for _, vs := range []*VerifiedShare{
+		nil,
+		{Share: verifiedShares[2].Share},
+		{Share: &tcpaillier.DecryptionShare{Index: 6, Ci: verifiedShares[2].Share.Ci}, Proof: verifiedShares[2].Proof},
+		{Share: &tcpaillier.DecryptionShare{Index: 3, Ci: big.NewInt(0)}, Proof: verifiedShares[2].Proof},
+		{Share: &tcpaillier.DecryptionShare{Index: 3, Ci: pk.N}, Proof: verifiedShares[2].Proof},
+	} {
+		if err := VerifyShare(pk, c, vs); err == nil {
+			t.Errorf("Expected error verifying share %v\n", vs)
+		}
+	}
+}
+
+func TestTallyVerifiedResults(t *testing.T) {
+	config := circom.BallotConfig{MaxCount: 2, MaxValue: 3, Base: 10}
+	shares, pk, err := tcpaillier.NewKey(128, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error generating key: %v\n", err)
+	}
+	tally, err := NewTally(pk, config)
+	if err != nil {
+		t.Fatalf("Error creating tally: %v\n", err)
+	}
+	for _, ballot := range [][]int{{1, 3}, {2, 0}} {
+		c, _, err := pk.Encrypt(circom.EncodeBallot(ballot, config))
+		if err != nil {
+			t.Fatalf("Error encrypting: %v\n", err)
+		}
+		if err := tally.Add(c); err != nil {
+			t.Fatalf("Error adding ciphertext: %v\n", err)
+		}
+	}
+	snapshot := tally.Snapshot()
+	verifiedShares := make([]*VerifiedShare, len(shares))
+	for i, share := range shares {
+		if verifiedShares[i], err = tally.PartialDecryptWithProof(share, snapshot); err != nil {
+			t.Fatalf("Error decrypting share %d: %v\n", i+1, err)
+		}
+	}
+	// a ballot added after the partial decryptions is not in the snapshot
+	late, _, err := pk.Encrypt(circom.EncodeBallot([]int{3, 3}, config))
+	if err != nil {
+		t.Fatalf("Error encrypting: %v\n", err)
+	}
+	if err := tally.Add(late); err != nil {
+		t.Fatalf("Error adding ciphertext: %v\n", err)
+	}
+	// trustee 2 publishes a wrong share
+	verifiedShares[1].Share.Ci = big.NewInt(1)
+	results, invalid, err := tally.VerifiedResults(snapshot, verifiedShares...)
+	if err != nil {
+		t.Fatalf("Error getting results: %v\n", err)
+	}
+	if len(invalid) != 1 || invalid[0] != 2 {
+		t.Errorf("Expected invalid trustees [2], got %v\n", invalid)
+	}
+	for i, expected := range []int64{3, 3} {
+		if results[i].Int64() != expected {
+			t.Errorf("Unexpected result for field %d: expected %d, got %s\n", i, expected, results[i])
+		}
+	}
+}
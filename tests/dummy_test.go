@@ -5,20 +5,9 @@ import (
 	"testing"
 
 	"github.com/niclabs/tcpaillier"
+	"github.com/vocdoni/paillier-sandbox/paillier"
 )
 
-func dummyEncrypt(g, nToS, nToSPlusOne, msg, r *big.Int) *big.Int {
-	// (n+1)^m % n^(s+1)
-	m := new(big.Int).Mod(msg, nToSPlusOne)
-	nPlusOneToM := new(big.Int).Exp(g, m, nToSPlusOne)
-	// r^(n^s) % n^(s+1)
-	rToNToS := new(big.Int).Exp(r, nToS, nToSPlusOne)
-	// (n+1)^m * r^(n^s) % n^(s+1)
-	c := new(big.Int).Mul(nPlusOneToM, rToNToS)
-	c.Mod(c, nToSPlusOne)
-	return c
-}
-
 func TestDummy(t *testing.T) {
 	// set parameters
 	bitSize := 254
@@ -46,8 +35,8 @@ func TestDummy(t *testing.T) {
 	}
 	// get the cached constant values
 	cv := pk.Cache()
-	// cipher raw with dummy encryption and compare with c1
-	if c2 := dummyEncrypt(cv.NPlusOne, cv.NToS, cv.NToSPlusOne, raw, r); c1.Cmp(c2) != 0 {
+	// cipher raw with the paillier package and compare with c1
+	if c2 := paillier.EncryptRaw(cv.NPlusOne, cv.NToS, cv.NToSPlusOne, raw, r); c1.Cmp(c2) != 0 {
 		t.Error("Ciphertexts are different")
 	}
 }